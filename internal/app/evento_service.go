@@ -0,0 +1,58 @@
+package app
+
+import (
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"errors"
+)
+
+// AssinaEventos passa a receber as alterações da lista; qualquer membro ativo pode acompanhar.
+// A função devolvida cancela a assinatura.
+func (s *ListaService) AssinaEventos(ctx context.Context, listaID int64, userID string) (<-chan listas.EventoLista, func(), error) {
+	if s.eventos == nil {
+		return nil, nil, errors.New("acompanhamento em tempo real desativado")
+	}
+	if err := s.VerificaMembro(ctx, listaID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	eventos, cancela := s.eventos.Assina(listaID)
+	return eventos, cancela, nil
+}
+
+// VerificaMembro confere se o usuário ainda é membro ativo da lista, para encerrar o acompanhamento de quem foi removido
+func (s *ListaService) VerificaMembro(ctx context.Context, listaID int64, userID string) error {
+	membro, err := s.repo.GetMembro(ctx, listaID, userID)
+	if err != nil {
+		return err
+	}
+	if membro == nil || !membro.Ativo() {
+		return listas.ErrListaNaoEncontrada
+	}
+	return nil
+}
+
+// publica é chamado só depois do commit: quem acompanha nunca vê uma alteração desfeita
+func (s *ListaService) publica(ctx context.Context, evento listas.EventoLista) {
+	if s.eventos == nil {
+		return
+	}
+	s.eventos.Publica(ctx, evento)
+}
+
+func (s *ListaService) publicaStatus(ctx context.Context, lista *listas.Lista, userID string) {
+	evento := listas.NovoEvento(listas.EventoStatusAlterado, lista, userID)
+	evento.Status = lista.Status
+	s.publica(ctx, evento)
+}
+
+// registraIntegracao grava o evento para os outros serviços na outbox, dentro da transação da alteração:
+// ele só é publicado (pelo relay) se a alteração for confirmada
+func registraIntegracao(ctx context.Context, repo interfaces.ListaRepository, evento listas.EventoIntegracao) error {
+	msg, err := listas.NovaMensagemOutbox(evento)
+	if err != nil {
+		return err
+	}
+	return repo.InsertOutbox(ctx, msg)
+}
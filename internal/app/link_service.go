@@ -0,0 +1,108 @@
+package app
+
+import (
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"time"
+)
+
+// CompartilhaLista cria um link público só de leitura, válido pela duração informada
+// (padrão de 72h, no máximo 30 dias). Só o dono pode compartilhar.
+func (s *ListaService) CompartilhaLista(ctx context.Context, listaID int64, userID string, validade time.Duration) (*listas.LinkCompartilhamento, string, error) {
+	if s.tokens == nil {
+		return nil, "", listas.ErrCompartilhamentoDesativado
+	}
+	switch {
+	case validade <= 0:
+		validade = listas.ValidadeLinkPadrao
+	case validade > listas.ValidadeLinkMaxima:
+		validade = listas.ValidadeLinkMaxima
+	}
+
+	link := &listas.LinkCompartilhamento{
+		ListaID:   listaID,
+		CriadoPor: userID,
+		ExpiraEm:  time.Now().Add(validade).Truncate(time.Second),
+	}
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		if _, _, err := acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeGerenciar); err != nil {
+			return err
+		}
+		return repo.CreateLink(ctx, link)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.tokens.Gera(link)
+	if err != nil {
+		return nil, "", err
+	}
+	return link, token, nil
+}
+
+// GetLinks lista os links ainda válidos da lista, para o dono poder revogá-los
+func (s *ListaService) GetLinks(ctx context.Context, listaID int64, userID string) ([]listas.LinkCompartilhamento, error) {
+	if _, _, err := acessaLista(ctx, s.repo, listaID, userID, listas.PapelMembro.PodeGerenciar); err != nil {
+		return nil, err
+	}
+
+	links, err := s.repo.GetLinksAtivos(ctx, listaID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []listas.LinkCompartilhamento{}
+	}
+	return links, nil
+}
+
+// RevogaLink invalida o link imediatamente, mesmo que o token ainda não tenha expirado
+func (s *ListaService) RevogaLink(ctx context.Context, listaID int64, userID string, linkID int64) error {
+	return s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		if _, _, err := acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeGerenciar); err != nil {
+			return err
+		}
+
+		link, err := repo.GetLink(ctx, linkID)
+		if err != nil {
+			return err
+		}
+		if link == nil || link.ListaID != listaID {
+			return listas.ErrLinkNaoEncontrado
+		}
+
+		return repo.RevogaLink(ctx, linkID, time.Now())
+	})
+}
+
+// GetListaPublica resolve o token de um link público. Token inválido, expirado ou revogado e lista
+// removida resultam todos em ErrLinkInvalido, para não revelar o motivo a quem não tem conta.
+func (s *ListaService) GetListaPublica(ctx context.Context, token string) (*listas.ListaPublica, error) {
+	if s.tokens == nil {
+		return nil, listas.ErrCompartilhamentoDesativado
+	}
+
+	linkID, err := s.tokens.Valida(token)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.repo.GetLink(ctx, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || !link.Valido(time.Now()) {
+		return nil, listas.ErrLinkInvalido
+	}
+
+	lista, err := s.repo.FindByID(ctx, link.ListaID)
+	if err != nil {
+		return nil, err
+	}
+	if lista == nil {
+		return nil, listas.ErrLinkInvalido
+	}
+	return listas.VisaoPublica(lista), nil
+}
@@ -3,113 +3,589 @@ package app
 import (
 	interfaces "comparei-servico-listas/internal/domain/interface"
 	"comparei-servico-listas/internal/domain/listas"
+	"context"
 	"errors"
+	"fmt"
+	"time"
 )
 
 type ListaService struct {
-	repo interfaces.ListaRepository
+	repo     interfaces.ListaRepository
+	politica listas.PoliticaListasAbertas
+	tokens   interfaces.TokenCompartilhamento // nil desativa os links públicos
+	eventos  interfaces.EventosLista          // nil desativa o acompanhamento em tempo real
 }
 
-func NewListaService(repo interfaces.ListaRepository) *ListaService {
-	return &ListaService{repo: repo}
+func NewListaService(repo interfaces.ListaRepository, politica listas.PoliticaListasAbertas, tokens interfaces.TokenCompartilhamento, eventos interfaces.EventosLista) *ListaService {
+	return &ListaService{repo: repo, politica: politica, tokens: tokens, eventos: eventos}
 }
 
-func (s *ListaService) CreateLista(lista *listas.Lista) (int64, error) {
-	hasOpen, err := s.repo.HasOpenList(lista.UserID)
+// CreateLista cria uma lista ABERTA, desde que o usuário não tenha atingido o limite de listas abertas do plano
+func (s *ListaService) CreateLista(ctx context.Context, lista *listas.Lista, plano string) (int64, error) {
+	var id int64
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		if err := s.verificaLimiteAbertas(ctx, repo, lista.UserID, plano); err != nil {
+			return err
+		}
+
+		lista.Status = listas.StatusAberta
+		lista.TotalPrevisto = 0
+		lista.TotalFinal = 0
+
+		var err error
+		id, err = repo.Create(ctx, lista)
+		if err != nil {
+			return err
+		}
+		if err := adicionaDono(ctx, repo, id, lista.UserID); err != nil {
+			return err
+		}
+
+		return registraIntegracao(ctx, repo, listas.ListaCriada{ListaID: id, UserID: lista.UserID, Nome: lista.Nome, TotalItens: len(lista.Itens)})
+	})
+	return id, err
+}
+
+// GetByID retorna a lista com os itens para qualquer membro ativo
+func (s *ListaService) GetByID(ctx context.Context, userID string, listaID int64) (*listas.Lista, error) {
+	lista, _, err := acessaLista(ctx, s.repo, listaID, userID, listas.PapelMembro.PodeVer)
+	return lista, err
+}
+
+// GetListaAtual retorna a lista ABERTA mais recente do usuário (a que ele está usando nas compras),
+// entre as dele e as compartilhadas com ele
+func (s *ListaService) GetListaAtual(ctx context.Context, userID string) (*listas.Lista, error) {
+	lista, err := s.repo.GetOpenListMembro(ctx, userID)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	if hasOpen {
-		return 0, errors.New("usuário já possui uma lista em aberto!")
+	if lista == nil {
+		return nil, fmt.Errorf("%w: usuário não possui lista em aberto", listas.ErrListaNaoEncontrada)
+	}
+	return lista, nil
+}
+
+// GetListasUsuario retorna uma página das listas do usuário; erros de validação do filtro são ErrFiltroInvalido
+func (s *ListaService) GetListasUsuario(ctx context.Context, userID string, filtro listas.FiltroListas) (*listas.PaginaListas, error) {
+	if err := filtro.Normaliza(); err != nil {
+		return nil, err
 	}
+	return s.repo.GetAll(ctx, userID, filtro)
+}
+
+func (s *ListaService) AddItem(ctx context.Context, userID string, item *listas.ItemLista) error {
+	var atualizada *listas.Lista
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		// 1. Validar se o usuário pode editar a lista
+		lista, _, err := acessaLista(ctx, repo, item.ListaID, userID, listas.PapelMembro.PodeEditar)
+		if err != nil {
+			return err
+		}
+		if lista.Status != listas.StatusAberta {
+			return listas.ErrListaNaoEditavel
+		}
+
+		// 2. Adicionar Item
+		err = repo.AddItem(ctx, item)
+		if err != nil {
+			return err
+		}
 
-	lista.Status = listas.StatusAberta
-	lista.TotalPrevisto = 0
-	lista.TotalFinal = 0
+		atualizada, err = recalculateTotals(ctx, repo, item.ListaID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
 
-	return s.repo.Create(lista)
+	evento := listas.NovoEvento(listas.EventoItemAdicionado, atualizada, userID)
+	evento.Item = item
+	s.publica(ctx, evento)
+	return nil
 }
 
-func (s *ListaService) GetByID(userID string, listaID int64) (*listas.Lista, error) {
-	return s.repo.GetByID(listaID, userID)
+func (s *ListaService) UpdateItem(ctx context.Context, userID string, listaID int64, itemID int64, alteracao listas.AlteracaoItem) (*listas.ItemLista, error) {
+	if alteracao.Quantidade != nil && *alteracao.Quantidade <= 0 {
+		return nil, errors.New("a quantidade deve ser maior que zero")
+	}
+	if alteracao.PrecoUnitario != nil && *alteracao.PrecoUnitario < 0 {
+		return nil, errors.New("o preço unitário não pode ser negativo")
+	}
+
+	var item *listas.ItemLista
+	var atualizada *listas.Lista
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		// 1. Validar se o usuário pode editar a lista e se ela ainda pode ser editada
+		lista, _, err := acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeEditar)
+		if err != nil {
+			return err
+		}
+		if lista.Status != listas.StatusAberta {
+			return listas.ErrListaNaoEditavel
+		}
+
+		// 2. Validar se o item é dessa lista
+		item, err = repo.GetItem(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.ListaID != lista.ID {
+			return listas.ErrItemNaoEncontrado
+		}
+
+		// 3. Aplicar alteração
+		item.Aplica(alteracao)
+		err = repo.UpdateItem(ctx, item)
+		if err != nil {
+			return err
+		}
+
+		atualizada, err = recalculateTotals(ctx, repo, lista.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	evento := listas.NovoEvento(listas.EventoItemAlterado, atualizada, userID)
+	evento.Item = item
+	s.publica(ctx, evento)
+	return item, nil
 }
 
-func (s *ListaService) GetListasUsuario(userID string) ([]*listas.Lista, error) {
-	return s.repo.GetAll(userID)
+// RemoveItem faz a remoção lógica do item, desde que o usuário possa editar a lista e ela esteja ABERTA
+func (s *ListaService) RemoveItem(ctx context.Context, userID string, listaID int64, itemID int64) error {
+	var atualizada *listas.Lista
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		item, err := repo.GetItem(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.ListaID != listaID {
+			return listas.ErrItemNaoEncontrado
+		}
+
+		// Como nas outras rotas, quem não é membro ativo não descobre que a lista ou o item existem:
+		// só o membro sem permissão de edição recebe ErrAcessoNegado
+		lista, _, err := acessaLista(ctx, repo, item.ListaID, userID, listas.PapelMembro.PodeEditar)
+		if errors.Is(err, listas.ErrListaNaoEncontrada) {
+			return listas.ErrItemNaoEncontrado
+		}
+		if err != nil {
+			return err
+		}
+		if lista.Status != listas.StatusAberta {
+			return listas.ErrListaNaoEditavel
+		}
+
+		err = repo.RemoveItem(ctx, itemID)
+		if err != nil {
+			return err
+		}
+
+		atualizada, err = recalculateTotals(ctx, repo, item.ListaID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	evento := listas.NovoEvento(listas.EventoItemRemovido, atualizada, userID)
+	evento.ItemID = itemID
+	s.publica(ctx, evento)
+	return nil
 }
 
-func (s *ListaService) AddItem(userID string, item *listas.ItemLista) error {
-	// 1. Validar se a lista pertence ao usuário
-	lista, err := s.repo.GetByID(item.ListaID, userID)
+func (s *ListaService) ToggleItemCheck(ctx context.Context, userID string, itemID int64, checked bool) error {
+	var item *listas.ItemLista
+	var atualizada *listas.Lista
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		var err error
+		item, err = repo.GetItem(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return listas.ErrItemNaoEncontrado
+		}
+
+		lista, _, err := acessaLista(ctx, repo, item.ListaID, userID, listas.PapelMembro.PodeEditar)
+		if err != nil {
+			return err
+		}
+		if lista.Status != listas.StatusAberta {
+			return listas.ErrListaNaoEditavel
+		}
+
+		comprado := checked && !item.Checked
+		item.Marca(checked, userID)
+		err = repo.UpdateItem(ctx, item)
+		if err != nil {
+			return err
+		}
+
+		// Só a passagem de não marcado para marcado é uma compra: marcar de novo um item já marcado não gera eventos,
+		// e desmarcar e marcar outra vez pelo mesmo preço gera os mesmos eventos, que a outbox grava uma vez só
+		if comprado {
+			compra := listas.ItemComprado{
+				ListaID:       item.ListaID,
+				ItemID:        item.ID,
+				UserID:        userID,
+				ProdutoID:     item.ProdutoID,
+				MercadoID:     item.MercadoID,
+				PrecoUnitario: item.PrecoUnitario,
+				Quantidade:    item.Quantidade,
+			}
+			// Confirma o preço para o Serviço Produtos e registra a compra no Serviço Logs
+			err = registraIntegracao(ctx, repo, compra)
+			if err != nil {
+				return err
+			}
+			err = registraIntegracao(ctx, repo, listas.LogEvento{
+				UserID:    userID,
+				ListaID:   item.ListaID,
+				Acao:      listas.AcaoItemComprado,
+				Descricao: fmt.Sprintf("Produto %d comprado na lista %d", item.ProdutoID, item.ListaID),
+				Chave:     compra.ChaveIdempotencia(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		atualizada, err = recalculateTotals(ctx, repo, item.ListaID)
+		return err
+	})
 	if err != nil {
 		return err
 	}
-	if lista == nil {
-		return errors.New("lista não encontrada ou acesso negado")
+
+	evento := listas.NovoEvento(listas.EventoItemMarcado, atualizada, userID)
+	evento.Item = item
+	s.publica(ctx, evento)
+	return nil
+}
+
+// FinalizaLista fecha a lista e congela o que foi pago. A partir daí lista e itens não podem mais ser editados.
+func (s *ListaService) FinalizaLista(ctx context.Context, listaID int64, userID string) error {
+	var lista *listas.Lista
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		var err error
+		lista, _, err = acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeEditar)
+		if err != nil {
+			return err
+		}
+
+		anterior := lista.Status
+		if err := lista.Finaliza(time.Now()); err != nil {
+			return err
+		}
+
+		if err := repo.UpdateStatus(ctx, lista, anterior); err != nil {
+			return err
+		}
+
+		// Snapshot do que foi pago em cada item comprado
+		finalizada := listas.ListaFinalizada{
+			ListaID:       lista.ID,
+			UserID:        userID,
+			TotalPrevisto: lista.TotalPrevisto,
+			TotalFinal:    lista.TotalFinal,
+			FinalizadaEm:  *lista.FinalizadaEm,
+		}
+		for i := range lista.Itens {
+			if !lista.Itens[i].Checked {
+				continue
+			}
+			if err := repo.UpdateItem(ctx, &lista.Itens[i]); err != nil {
+				return err
+			}
+			finalizada.ItensComprados++
+		}
+
+		return registraIntegracao(ctx, repo, finalizada)
+	})
+	if err != nil {
+		return err
 	}
-	if lista.Status != listas.StatusAberta {
-		return errors.New("não é possível editar uma lista fechada")
+
+	s.publicaStatus(ctx, lista, userID)
+	return nil
+}
+
+// CancelaLista abandona uma lista em aberto. Listas fechadas ou já canceladas não podem ser canceladas.
+func (s *ListaService) CancelaLista(ctx context.Context, listaID int64, userID string) error {
+	var lista *listas.Lista
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		var err error
+		lista, _, err = acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeGerenciar)
+		if err != nil {
+			return err
+		}
+
+		anterior := lista.Status
+		if err := lista.MudaStatus(listas.StatusCancelada); err != nil {
+			return err
+		}
+
+		return repo.UpdateStatus(ctx, lista, anterior)
+	})
+	if err != nil {
+		return err
 	}
 
-	// 2. Adicionar Item
-	err = s.repo.AddItem(item)
+	s.publicaStatus(ctx, lista, userID)
+	return nil
+}
+
+// ReabreLista devolve uma lista FECHADA ou CANCELADA para ABERTA, respeitando o limite de listas abertas do plano
+func (s *ListaService) ReabreLista(ctx context.Context, listaID int64, userID string, plano string) error {
+	var lista *listas.Lista
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		var err error
+		lista, _, err = acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeGerenciar)
+		if err != nil {
+			return err
+		}
+
+		// A transição é validada antes do limite: reabrir uma lista já aberta é transição inválida
+		anterior := lista.Status
+		if err := lista.Reabre(); err != nil {
+			return err
+		}
+
+		if err := s.verificaLimiteAbertas(ctx, repo, userID, plano); err != nil {
+			return err
+		}
+
+		if err := repo.UpdateStatus(ctx, lista, anterior); err != nil {
+			return err
+		}
+
+		// Descarta o snapshot da finalização anterior
+		for i := range lista.Itens {
+			if err := repo.UpdateItem(ctx, &lista.Itens[i]); err != nil {
+				return err
+			}
+		}
+
+		err = repo.InsertAuditoria(ctx, &listas.Auditoria{
+			ListaID:        lista.ID,
+			UserID:         userID,
+			Acao:           listas.AcaoReabertura,
+			StatusAnterior: anterior,
+			StatusNovo:     lista.Status,
+		})
+		if err != nil {
+			return err
+		}
+
+		return registraIntegracao(ctx, repo, listas.LogEvento{
+			UserID:    userID,
+			ListaID:   lista.ID,
+			Acao:      listas.AcaoReabertura,
+			Descricao: fmt.Sprintf("Lista %d reaberta (estava %s)", lista.ID, anterior),
+		})
+	})
 	if err != nil {
 		return err
 	}
 
-	return s.recalculateTotals(item.ListaID)
+	s.publicaStatus(ctx, lista, userID)
+	return nil
 }
 
-func (s *ListaService) RemoveItem(itemID int64) error {
-	return s.repo.RemoveItem(itemID)
+// DuplicaLista copia os itens de uma lista (de qualquer status) para uma nova lista ABERTA.
+// As marcações são zeradas e os preços atualizados quando o último preço do mercado é conhecido.
+func (s *ListaService) DuplicaLista(ctx context.Context, listaID int64, userID string, plano string, nome string) (int64, error) {
+	var novaID int64
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		// Qualquer membro pode duplicar: a cópia é uma lista nova, da qual ele é o dono
+		origem, _, err := acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeVer)
+		if err != nil {
+			return err
+		}
+
+		if err := s.verificaLimiteAbertas(ctx, repo, userID, plano); err != nil {
+			return err
+		}
+
+		if nome == "" {
+			nome = origem.Nome
+		}
+
+		nova := &listas.Lista{
+			UserID: userID,
+			Nome:   nome,
+			Status: listas.StatusAberta,
+		}
+
+		for _, item := range origem.Itens {
+			copia := listas.ItemLista{
+				ProdutoID:     item.ProdutoID,
+				MercadoID:     item.MercadoID,
+				Quantidade:    item.Quantidade,
+				PrecoUnitario: item.PrecoUnitario,
+				Checked:       false,
+			}
+
+			if item.MercadoID != nil {
+				preco, err := repo.GetPrecoAtual(ctx, item.ProdutoID, *item.MercadoID)
+				if err != nil {
+					return err
+				}
+				if preco != nil {
+					copia.PrecoUnitario = *preco
+				}
+			}
+
+			nova.Itens = append(nova.Itens, copia)
+		}
+
+		nova.RecalculaTotais()
+
+		novaID, err = repo.Create(ctx, nova)
+		if err != nil {
+			return err
+		}
+		if err := adicionaDono(ctx, repo, novaID, userID); err != nil {
+			return err
+		}
+
+		for i := range nova.Itens {
+			nova.Itens[i].ListaID = novaID
+			if err := repo.AddItem(ctx, &nova.Itens[i]); err != nil {
+				return err
+			}
+		}
+
+		return registraIntegracao(ctx, repo, listas.ListaCriada{ListaID: novaID, UserID: userID, Nome: nova.Nome, DuplicadaDe: &listaID, TotalItens: len(nova.Itens)})
+	})
+	return novaID, err
 }
 
-func (s *ListaService) ToggleItemCheck(userID string, itemID int64, checked bool) error {
-	item, err := s.repo.GetItem(itemID)
+// UpdatePricesFromEvent aplica o novo preço, alterado no Promer em modificadoEm, nas listas abertas e recalcula os
+// totais das listas afetadas, tudo na mesma transação. Retorna os IDs das listas afetadas; nenhuma se já houver
+// um preço mais recente para o produto no mercado (evento atrasado ou reprocessado).
+func (s *ListaService) UpdatePricesFromEvent(ctx context.Context, produtoID int64, mercadoID int64, novoPreco float64, modificadoEm time.Time) ([]int64, error) {
+	var listaIDs []int64
+	var atualizadas []*listas.Lista
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		// Guarda o último preço conhecido, usado para atualizar listas duplicadas
+		aceito, err := repo.SavePrecoAtual(ctx, produtoID, mercadoID, novoPreco, modificadoEm)
+		if err != nil || !aceito {
+			return err
+		}
+
+		listaIDs, err = repo.UpdatePriceInOpenLists(ctx, produtoID, mercadoID, novoPreco)
+		if err != nil {
+			return err
+		}
+
+		for _, listaID := range listaIDs {
+			lista, err := recalculateTotals(ctx, repo, listaID)
+			if err != nil {
+				return err
+			}
+			atualizadas = append(atualizadas, lista)
+		}
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	for _, lista := range atualizadas {
+		evento := listas.NovoEvento(listas.EventoPrecoAlterado, lista, "")
+		evento.ProdutoID = produtoID
+		evento.MercadoID = mercadoID
+		evento.Preco = &novoPreco
+		s.publica(ctx, evento)
 	}
+	return listaIDs, nil
+}
 
-	lista, err := s.repo.GetByID(item.ListaID, userID)
-	if err != nil || lista == nil {
-		return errors.New("lista não encontrada")
+// verificaLimiteAbertas falha com LimiteListasAbertasError se o usuário já tem o máximo de listas abertas do plano.
+// Roda dentro da transação que abre a lista: a trava do usuário segura as outras criações dele até o commit.
+func (s *ListaService) verificaLimiteAbertas(ctx context.Context, repo interfaces.ListaRepository, userID string, plano string) error {
+	limite := s.politica.Limite(plano)
+	if limite <= 0 {
+		return nil
 	}
 
-	item.Checked = checked
-	err = s.repo.UpdateItem(item)
+	if err := repo.TravaUsuario(ctx, userID); err != nil {
+		return err
+	}
+	abertas, err := repo.CountOpenLists(ctx, userID)
 	if err != nil {
 		return err
 	}
+	if abertas < limite {
+		return nil
+	}
 
-	// if checked {
-	// 	// Publicar evento para confirmar preço (Serviço Produtos)
-	// 	go publisher.PubConfirmarPreco(item.ProdutoID, item.MercadoID, item.PrecoUnitario)
+	recente, err := repo.GetOpenList(ctx, userID)
+	if err != nil {
+		return err
+	}
+	erro := &listas.LimiteListasAbertasError{Limite: limite}
+	if recente != nil {
+		erro.ListaID = recente.ID
+	}
+	return erro
+}
 
-	// 	// Publicar log (Serviço Logs)
-	// 	go publisher.PubLogEvento(userID, "ITEM_COMPRADO", fmt.Sprintf("Produto %d comprado na lista %d", item.ProdutoID, item.ListaID))
-	// }
+// acessaLista carrega a lista (com os itens) e a participação do usuário nela.
+// Quem não é membro ativo não enxerga a lista (ErrListaNaoEncontrada); membro sem a permissão exigida recebe ErrAcessoNegado.
+func acessaLista(ctx context.Context, repo interfaces.ListaRepository, listaID int64, userID string, permite func(listas.PapelMembro) bool) (*listas.Lista, *listas.Membro, error) {
+	membro, err := repo.GetMembro(ctx, listaID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if membro == nil || !membro.Ativo() {
+		return nil, nil, listas.ErrListaNaoEncontrada
+	}
 
-	return s.recalculateTotals(item.ListaID)
-}
+	lista, err := repo.FindByID(ctx, listaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if lista == nil {
+		return nil, nil, listas.ErrListaNaoEncontrada
+	}
 
-func (s *ListaService) FinalizaLista(listaID int64, userID string) error {
-	return s.repo.FinalizaLista(listaID, userID)
+	if !permite(membro.Papel) {
+		return nil, nil, listas.ErrAcessoNegado
+	}
+	return lista, membro, nil
 }
 
-// Método auxiliar de cálculo
-func (s *ListaService) recalculateTotals(listaID int64) error {
-	// Busca lista completa com itens
-	// Itera somando (Quantidade * Preco)
-	// Atualiza tabela 'listas'
-	// *Nota: Implementação detalhada depende do Repository retornando itens*
-	return nil
+// adicionaDono registra o criador como dono da lista recém-criada
+func adicionaDono(ctx context.Context, repo interfaces.ListaRepository, listaID int64, userID string) error {
+	agora := time.Now()
+	return repo.AddMembro(ctx, &listas.Membro{
+		ListaID:      listaID,
+		UserID:       userID,
+		Papel:        listas.PapelDono,
+		ConvidadoPor: userID,
+		AceitoEm:     &agora,
+	})
 }
 
-func (s *ListaService) UpdatePricesFromEvent(produtoID int64, mercadoID int64, novoPreco float64) error {
-	err := s.repo.UpdatePriceInOpenLists(produtoID, mercadoID, novoPreco)
+// Método auxiliar de cálculo: recarrega a lista com os itens e persiste os totais. Retorna a lista atualizada.
+func recalculateTotals(ctx context.Context, repo interfaces.ListaRepository, listaID int64) (*listas.Lista, error) {
+	lista, err := repo.FindByID(ctx, listaID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if lista == nil {
+		return nil, listas.ErrListaNaoEncontrada
 	}
 
-	return nil
+	lista.RecalculaTotais()
+
+	if err := repo.Update(ctx, lista); err != nil {
+		return nil, err
+	}
+	return lista, nil
 }
@@ -0,0 +1,338 @@
+package app_test
+
+import (
+	"comparei-servico-listas/internal/app"
+	"comparei-servico-listas/internal/domain/listas"
+	"comparei-servico-listas/internal/infrastructure/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const dono = "user-1"
+
+func novoService(t *testing.T) (*app.ListaService, *repository.MemoryRepository) {
+	t.Helper()
+	repo := repository.NewMemoryRepository()
+	return app.NewListaService(repo, listas.PoliticaUmaListaAberta, nil, nil), repo
+}
+
+func criaLista(t *testing.T, service *app.ListaService) int64 {
+	t.Helper()
+	id, err := service.CreateLista(context.Background(), &listas.Lista{UserID: dono, Nome: "Mercado"}, "")
+	if err != nil {
+		t.Fatalf("CreateLista: %v", err)
+	}
+	return id
+}
+
+func TestCancelaListaAberta(t *testing.T) {
+	ctx := context.Background()
+	service, repo := novoService(t)
+	id := criaLista(t, service)
+
+	if err := service.CancelaLista(ctx, id, dono); err != nil {
+		t.Fatalf("CancelaLista: %v", err)
+	}
+
+	lista, err := repo.FindByID(ctx, id)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if lista.Status != listas.StatusCancelada {
+		t.Fatalf("status = %s, esperado %s", lista.Status, listas.StatusCancelada)
+	}
+
+	// A lista cancelada deixa de ocupar a vaga de lista aberta
+	abertas, err := repo.CountOpenLists(ctx, dono)
+	if err != nil {
+		t.Fatalf("CountOpenLists: %v", err)
+	}
+	if abertas != 0 {
+		t.Fatalf("CountOpenLists = %d, esperado 0", abertas)
+	}
+	if _, err := service.CreateLista(ctx, &listas.Lista{UserID: dono, Nome: "Outra"}, ""); err != nil {
+		t.Fatalf("CreateLista depois de cancelar: %v", err)
+	}
+}
+
+func TestCancelaListaNaoAberta(t *testing.T) {
+	casos := []struct {
+		nome    string
+		prepara func(ctx context.Context, service *app.ListaService, id int64) error
+	}{
+		{"FECHADA", func(ctx context.Context, service *app.ListaService, id int64) error {
+			return service.FinalizaLista(ctx, id, dono)
+		}},
+		{"CANCELADA", func(ctx context.Context, service *app.ListaService, id int64) error {
+			return service.CancelaLista(ctx, id, dono)
+		}},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := novoService(t)
+			id := criaLista(t, service)
+			if err := c.prepara(ctx, service, id); err != nil {
+				t.Fatalf("preparando lista %s: %v", c.nome, err)
+			}
+
+			err := service.CancelaLista(ctx, id, dono)
+			if !errors.Is(err, listas.ErrTransicaoInvalida) {
+				t.Fatalf("CancelaLista: erro = %v, esperado ErrTransicaoInvalida (409)", err)
+			}
+		})
+	}
+}
+
+func TestMarcaItemRegistraCompraUmaVez(t *testing.T) {
+	ctx := context.Background()
+	service, repo := novoService(t)
+	listaID := criaLista(t, service)
+
+	item := &listas.ItemLista{ListaID: listaID, ProdutoID: 7, PrecoUnitario: 4.5, Quantidade: 2}
+	if err := service.AddItem(ctx, dono, item); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+
+	// Marcar, marcar de novo, desmarcar e marcar outra vez pelo mesmo preço é uma compra só
+	for _, checked := range []bool{true, true, false, true} {
+		if err := service.ToggleItemCheck(ctx, dono, item.ID, checked); err != nil {
+			t.Fatalf("ToggleItemCheck(%v): %v", checked, err)
+		}
+	}
+	if n := contaOutbox(t, repo, listas.TipoItemComprado); n != 1 {
+		t.Fatalf("%d eventos %s, esperado 1", n, listas.TipoItemComprado)
+	}
+	if n := contaOutbox(t, repo, listas.TipoLogEvento); n != 1 {
+		t.Fatalf("%d eventos %s, esperado 1", n, listas.TipoLogEvento)
+	}
+
+	// Com outro preço, a compra é outra
+	preco := 5.0
+	if _, err := service.UpdateItem(ctx, dono, listaID, item.ID, listas.AlteracaoItem{PrecoUnitario: &preco}); err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	for _, checked := range []bool{false, true} {
+		if err := service.ToggleItemCheck(ctx, dono, item.ID, checked); err != nil {
+			t.Fatalf("ToggleItemCheck(%v): %v", checked, err)
+		}
+	}
+	if n := contaOutbox(t, repo, listas.TipoItemComprado); n != 2 {
+		t.Fatalf("%d eventos %s, esperado 2", n, listas.TipoItemComprado)
+	}
+}
+
+func contaOutbox(t *testing.T, repo *repository.MemoryRepository, tipo string) int {
+	t.Helper()
+	pendentes, err := repo.GetOutboxPendentes(context.Background(), time.Now().Add(time.Hour), 1000)
+	if err != nil {
+		t.Fatalf("GetOutboxPendentes: %v", err)
+	}
+	n := 0
+	for _, m := range pendentes {
+		if m.Tipo == tipo {
+			n++
+		}
+	}
+	return n
+}
+
+// confereTotais compara os totais gravados da lista com os esperados
+func confereTotais(t *testing.T, repo *repository.MemoryRepository, listaID int64, previsto, final float64) {
+	t.Helper()
+	lista, err := repo.FindByID(context.Background(), listaID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if lista.TotalPrevisto != previsto || lista.TotalFinal != final {
+		t.Fatalf("totais = %.2f/%.2f, esperado %.2f/%.2f", lista.TotalPrevisto, lista.TotalFinal, previsto, final)
+	}
+}
+
+func TestTotaisAcompanhamOsItens(t *testing.T) {
+	ctx := context.Background()
+	service, repo := novoService(t)
+	listaID := criaLista(t, service)
+	mercado := int64(7)
+
+	arroz := &listas.ItemLista{ListaID: listaID, ProdutoID: 1, MercadoID: &mercado, PrecoUnitario: 10, Quantidade: 2}
+	if err := service.AddItem(ctx, dono, arroz); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	feijao := &listas.ItemLista{ListaID: listaID, ProdutoID: 2, PrecoUnitario: 3.5, Quantidade: 1}
+	if err := service.AddItem(ctx, dono, feijao); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	confereTotais(t, repo, listaID, 23.5, 0)
+
+	quantidade := 3.0
+	if _, err := service.UpdateItem(ctx, dono, listaID, arroz.ID, listas.AlteracaoItem{Quantidade: &quantidade}); err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	confereTotais(t, repo, listaID, 33.5, 0)
+
+	// Só os itens marcados entram no total final
+	if err := service.ToggleItemCheck(ctx, dono, feijao.ID, true); err != nil {
+		t.Fatalf("ToggleItemCheck: %v", err)
+	}
+	confereTotais(t, repo, listaID, 33.5, 3.5)
+
+	afetadas, err := service.UpdatePricesFromEvent(ctx, 1, mercado, 12, time.Now())
+	if err != nil {
+		t.Fatalf("UpdatePricesFromEvent: %v", err)
+	}
+	if len(afetadas) != 1 || afetadas[0] != listaID {
+		t.Fatalf("listas afetadas = %v, esperado [%d]", afetadas, listaID)
+	}
+	confereTotais(t, repo, listaID, 39.5, 3.5)
+
+	if err := service.RemoveItem(ctx, dono, listaID, feijao.ID); err != nil {
+		t.Fatalf("RemoveItem: %v", err)
+	}
+	confereTotais(t, repo, listaID, 36, 0)
+}
+
+func TestLimiteListasAbertas(t *testing.T) {
+	ctx := context.Background()
+	service, _ := novoService(t)
+	primeira := criaLista(t, service)
+
+	_, err := service.CreateLista(ctx, &listas.Lista{UserID: dono, Nome: "Segunda"}, "")
+	var errLimite *listas.LimiteListasAbertasError
+	if !errors.As(err, &errLimite) {
+		t.Fatalf("CreateLista além do limite = %v, esperado LimiteListasAbertasError", err)
+	}
+	if errLimite.Limite != 1 || errLimite.ListaID != primeira {
+		t.Fatalf("erro = %+v, esperado limite 1 e lista_id %d", errLimite, primeira)
+	}
+
+	// O limite é por usuário
+	if _, err := service.CreateLista(ctx, &listas.Lista{UserID: "outro", Nome: "Dele"}, ""); err != nil {
+		t.Fatalf("CreateLista de outro usuário: %v", err)
+	}
+}
+
+func TestReabreListaRespeitaLimite(t *testing.T) {
+	ctx := context.Background()
+	service, repo := novoService(t)
+	cancelada := criaLista(t, service)
+	if err := service.CancelaLista(ctx, cancelada, dono); err != nil {
+		t.Fatalf("CancelaLista: %v", err)
+	}
+	aberta := criaLista(t, service)
+
+	err := service.ReabreLista(ctx, cancelada, dono, "")
+	var errLimite *listas.LimiteListasAbertasError
+	if !errors.As(err, &errLimite) {
+		t.Fatalf("ReabreLista com outra lista aberta = %v, esperado LimiteListasAbertasError", err)
+	}
+	if errLimite.ListaID != aberta {
+		t.Fatalf("lista_id = %d, esperado a lista aberta %d", errLimite.ListaID, aberta)
+	}
+	lista, err := repo.FindByID(ctx, cancelada)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if lista.Status != listas.StatusCancelada {
+		t.Fatalf("status = %s depois da recusa, esperado %s", lista.Status, listas.StatusCancelada)
+	}
+
+	// Liberada a vaga, a reabertura passa
+	if err := service.CancelaLista(ctx, aberta, dono); err != nil {
+		t.Fatalf("CancelaLista: %v", err)
+	}
+	if err := service.ReabreLista(ctx, cancelada, dono, ""); err != nil {
+		t.Fatalf("ReabreLista: %v", err)
+	}
+	if lista, _ := repo.FindByID(ctx, cancelada); lista.Status != listas.StatusAberta {
+		t.Fatalf("status = %s, esperado %s", lista.Status, listas.StatusAberta)
+	}
+}
+
+func TestDuplicaListaZeraMarcacoesEAtualizaPrecos(t *testing.T) {
+	ctx := context.Background()
+	service, repo := novoService(t)
+	origem := criaLista(t, service)
+	mercado := int64(7)
+
+	arroz := &listas.ItemLista{ListaID: origem, ProdutoID: 1, MercadoID: &mercado, PrecoUnitario: 10, Quantidade: 2}
+	feijao := &listas.ItemLista{ListaID: origem, ProdutoID: 2, PrecoUnitario: 3.5, Quantidade: 1}
+	for _, item := range []*listas.ItemLista{arroz, feijao} {
+		if err := service.AddItem(ctx, dono, item); err != nil {
+			t.Fatalf("AddItem: %v", err)
+		}
+		if err := service.ToggleItemCheck(ctx, dono, item.ID, true); err != nil {
+			t.Fatalf("ToggleItemCheck: %v", err)
+		}
+	}
+	if err := service.FinalizaLista(ctx, origem, dono); err != nil {
+		t.Fatalf("FinalizaLista: %v", err)
+	}
+
+	// A lista fechada não muda, mas o preço fica guardado para a cópia
+	if _, err := service.UpdatePricesFromEvent(ctx, 1, mercado, 12, time.Now()); err != nil {
+		t.Fatalf("UpdatePricesFromEvent: %v", err)
+	}
+
+	copiaID, err := service.DuplicaLista(ctx, origem, dono, "", "")
+	if err != nil {
+		t.Fatalf("DuplicaLista: %v", err)
+	}
+	copia, err := repo.FindByID(ctx, copiaID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if copia.Status != listas.StatusAberta || copia.Nome != "Mercado" || len(copia.Itens) != 2 {
+		t.Fatalf("cópia = %+v, esperado lista ABERTA \"Mercado\" com 2 itens", copia)
+	}
+	precos := map[int64]float64{1: 12, 2: 3.5}
+	for _, item := range copia.Itens {
+		if item.Checked {
+			t.Fatalf("item %d da cópia continua marcado", item.ProdutoID)
+		}
+		if item.PrecoUnitario != precos[item.ProdutoID] {
+			t.Fatalf("preço do produto %d = %.2f, esperado %.2f", item.ProdutoID, item.PrecoUnitario, precos[item.ProdutoID])
+		}
+	}
+	confereTotais(t, repo, copiaID, 27.5, 0)
+
+	// A origem continua como foi fechada
+	confereTotais(t, repo, origem, 23.5, 23.5)
+}
+
+func TestEventoDePrecoAntigoEIgnorado(t *testing.T) {
+	ctx := context.Background()
+	service, repo := novoService(t)
+	listaID := criaLista(t, service)
+	mercado := int64(7)
+	item := &listas.ItemLista{ListaID: listaID, ProdutoID: 1, MercadoID: &mercado, PrecoUnitario: 10, Quantidade: 1}
+	if err := service.AddItem(ctx, dono, item); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+
+	alterado := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	if _, err := service.UpdatePricesFromEvent(ctx, 1, mercado, 12, alterado); err != nil {
+		t.Fatalf("UpdatePricesFromEvent: %v", err)
+	}
+
+	// Um evento atrasado (ou reprocessado da dead-letter) não desfaz o preço mais novo
+	afetadas, err := service.UpdatePricesFromEvent(ctx, 1, mercado, 11, alterado.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("UpdatePricesFromEvent antigo: %v", err)
+	}
+	if len(afetadas) != 0 {
+		t.Fatalf("listas afetadas pelo evento antigo = %v, esperado nenhuma", afetadas)
+	}
+	if preco, _ := repo.GetPrecoAtual(ctx, 1, mercado); preco == nil || *preco != 12 {
+		t.Fatalf("preço atual = %v, esperado 12", preco)
+	}
+	confereTotais(t, repo, listaID, 12, 0)
+
+	if _, err := service.UpdatePricesFromEvent(ctx, 1, mercado, 13, alterado.Add(time.Minute)); err != nil {
+		t.Fatalf("UpdatePricesFromEvent: %v", err)
+	}
+	confereTotais(t, repo, listaID, 13, 0)
+}
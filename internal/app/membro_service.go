@@ -0,0 +1,125 @@
+package app
+
+import (
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"errors"
+	"time"
+)
+
+// ConvidaMembro cria um convite pendente para convidadoID. Só o dono da lista pode convidar.
+func (s *ListaService) ConvidaMembro(ctx context.Context, listaID int64, userID string, convidadoID string, papel listas.PapelMembro) (*listas.Membro, error) {
+	if convidadoID == "" {
+		return nil, errors.New("informe o usuário convidado")
+	}
+	if !papel.Convidavel() {
+		return nil, listas.ErrPapelInvalido
+	}
+
+	var convite *listas.Membro
+	err := s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		if _, _, err := acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeGerenciar); err != nil {
+			return err
+		}
+
+		existente, err := repo.GetMembro(ctx, listaID, convidadoID)
+		if err != nil {
+			return err
+		}
+		if existente != nil {
+			return listas.ErrMembroExistente
+		}
+
+		convite = &listas.Membro{
+			ListaID:      listaID,
+			UserID:       convidadoID,
+			Papel:        papel,
+			ConvidadoPor: userID,
+		}
+		return repo.AddMembro(ctx, convite)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convite, nil
+}
+
+// AceitaConvite ativa a participação do usuário na lista. Aceitar de novo não tem efeito.
+func (s *ListaService) AceitaConvite(ctx context.Context, listaID int64, userID string) error {
+	return s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		convite, err := repo.GetMembro(ctx, listaID, userID)
+		if err != nil {
+			return err
+		}
+		if convite == nil {
+			return listas.ErrMembroNaoEncontrado
+		}
+		if convite.Ativo() {
+			return nil
+		}
+
+		lista, err := repo.FindByID(ctx, listaID)
+		if err != nil {
+			return err
+		}
+		if lista == nil {
+			return listas.ErrListaNaoEncontrada
+		}
+
+		return repo.AceitaConvite(ctx, listaID, userID, time.Now())
+	})
+}
+
+// RemoveMembro revoga a participação (ou o convite) de membroID. O dono remove qualquer membro;
+// os demais só podem remover a si mesmos, para sair da lista ou recusar o convite. O dono não sai da lista.
+func (s *ListaService) RemoveMembro(ctx context.Context, listaID int64, userID string, membroID string) error {
+	return s.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		if membroID != userID {
+			if _, _, err := acessaLista(ctx, repo, listaID, userID, listas.PapelMembro.PodeGerenciar); err != nil {
+				return err
+			}
+		}
+
+		membro, err := repo.GetMembro(ctx, listaID, membroID)
+		if err != nil {
+			return err
+		}
+		if membro == nil {
+			return listas.ErrMembroNaoEncontrado
+		}
+		if membro.Papel == listas.PapelDono {
+			return listas.ErrAcessoNegado
+		}
+
+		return repo.RemoveMembro(ctx, listaID, membroID)
+	})
+}
+
+// GetMembros lista os membros e convites pendentes; qualquer membro ativo pode consultar
+func (s *ListaService) GetMembros(ctx context.Context, listaID int64, userID string) ([]listas.Membro, error) {
+	if _, _, err := acessaLista(ctx, s.repo, listaID, userID, listas.PapelMembro.PodeVer); err != nil {
+		return nil, err
+	}
+
+	membros, err := s.repo.GetMembros(ctx, listaID)
+	if err != nil {
+		return nil, err
+	}
+	if membros == nil {
+		membros = []listas.Membro{}
+	}
+	return membros, nil
+}
+
+// GetConvites retorna os convites que o usuário ainda não aceitou
+func (s *ListaService) GetConvites(ctx context.Context, userID string) ([]listas.Membro, error) {
+	convites, err := s.repo.GetConvitesPendentes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if convites == nil {
+		convites = []listas.Membro{}
+	}
+	return convites, nil
+}
@@ -0,0 +1,180 @@
+package app_test
+
+import (
+	"comparei-servico-listas/internal/app"
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"errors"
+	"testing"
+)
+
+// compartilha convida userID para a lista do dono com o papel informado e, se aceita, aceita o convite
+func compartilha(t *testing.T, service *app.ListaService, listaID int64, userID string, papel listas.PapelMembro, aceita bool) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := service.ConvidaMembro(ctx, listaID, dono, userID, papel); err != nil {
+		t.Fatalf("ConvidaMembro: %v", err)
+	}
+	if !aceita {
+		return
+	}
+	if err := service.AceitaConvite(ctx, listaID, userID); err != nil {
+		t.Fatalf("AceitaConvite: %v", err)
+	}
+}
+
+// listaComItem cria a lista do dono com um item
+func listaComItem(t *testing.T, service *app.ListaService) (int64, int64) {
+	t.Helper()
+	listaID := criaLista(t, service)
+	item := &listas.ItemLista{ListaID: listaID, ProdutoID: 1, PrecoUnitario: 10, Quantidade: 1}
+	if err := service.AddItem(context.Background(), dono, item); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	return listaID, item.ID
+}
+
+// operacoesDeItem são as alterações de itens que exigem permissão de edição
+func operacoesDeItem(service *app.ListaService, listaID, itemID int64) map[string]func(userID string) error {
+	ctx := context.Background()
+	quantidade := 2.0
+	return map[string]func(userID string) error{
+		"AddItem": func(userID string) error {
+			return service.AddItem(ctx, userID, &listas.ItemLista{ListaID: listaID, ProdutoID: 2, PrecoUnitario: 5, Quantidade: 1})
+		},
+		"UpdateItem": func(userID string) error {
+			_, err := service.UpdateItem(ctx, userID, listaID, itemID, listas.AlteracaoItem{Quantidade: &quantidade})
+			return err
+		},
+		"ToggleItemCheck": func(userID string) error { return service.ToggleItemCheck(ctx, userID, itemID, true) },
+		"RemoveItem":      func(userID string) error { return service.RemoveItem(ctx, userID, listaID, itemID) },
+	}
+}
+
+func TestLeitorNaoEditaItens(t *testing.T) {
+	service, _ := novoService(t)
+	listaID, itemID := listaComItem(t, service)
+	compartilha(t, service, listaID, "leitor", listas.PapelLeitor, true)
+
+	if _, err := service.GetByID(context.Background(), "leitor", listaID); err != nil {
+		t.Fatalf("GetByID do leitor: %v", err)
+	}
+	for nome, operacao := range operacoesDeItem(service, listaID, itemID) {
+		t.Run(nome, func(t *testing.T) {
+			if err := operacao("leitor"); !errors.Is(err, listas.ErrAcessoNegado) {
+				t.Fatalf("erro = %v, esperado %v", err, listas.ErrAcessoNegado)
+			}
+		})
+	}
+}
+
+func TestEditorNaoGerenciaLista(t *testing.T) {
+	ctx := context.Background()
+	service, _ := novoService(t)
+	listaID, itemID := listaComItem(t, service)
+	compartilha(t, service, listaID, "editor", listas.PapelEditor, true)
+	compartilha(t, service, listaID, "leitor", listas.PapelLeitor, true)
+
+	// O editor altera os itens...
+	quantidade := 3.0
+	if _, err := service.UpdateItem(ctx, "editor", listaID, itemID, listas.AlteracaoItem{Quantidade: &quantidade}); err != nil {
+		t.Fatalf("UpdateItem do editor: %v", err)
+	}
+
+	// ...mas não gerencia a lista nem os membros
+	casos := []struct {
+		nome     string
+		operacao func() error
+	}{
+		{"CancelaLista", func() error { return service.CancelaLista(ctx, listaID, "editor") }},
+		{"ConvidaMembro", func() error {
+			_, err := service.ConvidaMembro(ctx, listaID, "editor", "outro", listas.PapelLeitor)
+			return err
+		}},
+		{"RemoveMembro", func() error { return service.RemoveMembro(ctx, listaID, "editor", "leitor") }},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if err := c.operacao(); !errors.Is(err, listas.ErrAcessoNegado) {
+				t.Fatalf("erro = %v, esperado %v", err, listas.ErrAcessoNegado)
+			}
+		})
+	}
+
+	if err := service.CancelaLista(ctx, listaID, dono); err != nil {
+		t.Fatalf("CancelaLista do dono: %v", err)
+	}
+	if err := service.ReabreLista(ctx, listaID, "editor", ""); !errors.Is(err, listas.ErrAcessoNegado) {
+		t.Fatalf("ReabreLista do editor = %v, esperado %v", err, listas.ErrAcessoNegado)
+	}
+}
+
+func TestConvitePendenteNaoLeLista(t *testing.T) {
+	ctx := context.Background()
+	service, _ := novoService(t)
+	listaID := criaLista(t, service)
+	compartilha(t, service, listaID, "convidado", listas.PapelEditor, false)
+
+	if _, err := service.GetByID(ctx, "convidado", listaID); !errors.Is(err, listas.ErrListaNaoEncontrada) {
+		t.Fatalf("GetByID = %v, esperado %v", err, listas.ErrListaNaoEncontrada)
+	}
+	if _, err := service.GetMembros(ctx, listaID, "convidado"); !errors.Is(err, listas.ErrListaNaoEncontrada) {
+		t.Fatalf("GetMembros = %v, esperado %v", err, listas.ErrListaNaoEncontrada)
+	}
+
+	// Depois de aceitar, passa a ler
+	if err := service.AceitaConvite(ctx, listaID, "convidado"); err != nil {
+		t.Fatalf("AceitaConvite: %v", err)
+	}
+	if _, err := service.GetByID(ctx, "convidado", listaID); err != nil {
+		t.Fatalf("GetByID depois de aceitar: %v", err)
+	}
+}
+
+func TestNaoMembroNaoDescobreALista(t *testing.T) {
+	ctx := context.Background()
+	service, _ := novoService(t)
+	listaID, itemID := listaComItem(t, service)
+
+	if _, err := service.GetByID(ctx, "estranho", listaID); !errors.Is(err, listas.ErrListaNaoEncontrada) {
+		t.Fatalf("GetByID = %v, esperado %v", err, listas.ErrListaNaoEncontrada)
+	}
+	if err := service.CancelaLista(ctx, listaID, "estranho"); !errors.Is(err, listas.ErrListaNaoEncontrada) {
+		t.Fatalf("CancelaLista = %v, esperado %v", err, listas.ErrListaNaoEncontrada)
+	}
+	if _, err := service.ConvidaMembro(ctx, listaID, "estranho", "outro", listas.PapelLeitor); !errors.Is(err, listas.ErrListaNaoEncontrada) {
+		t.Fatalf("ConvidaMembro = %v, esperado %v", err, listas.ErrListaNaoEncontrada)
+	}
+
+	// Nas operações de itens, o 404 é da lista ou do item, nunca um 403
+	for nome, operacao := range operacoesDeItem(service, listaID, itemID) {
+		t.Run(nome, func(t *testing.T) {
+			err := operacao("estranho")
+			if !errors.Is(err, listas.ErrListaNaoEncontrada) && !errors.Is(err, listas.ErrItemNaoEncontrado) {
+				t.Fatalf("erro = %v, esperado lista ou item não encontrado", err)
+			}
+		})
+	}
+}
+
+func TestDonoNaoERemovido(t *testing.T) {
+	ctx := context.Background()
+	service, repo := novoService(t)
+	listaID := criaLista(t, service)
+	compartilha(t, service, listaID, "editor", listas.PapelEditor, true)
+
+	if err := service.RemoveMembro(ctx, listaID, dono, dono); !errors.Is(err, listas.ErrAcessoNegado) {
+		t.Fatalf("dono saindo da lista = %v, esperado %v", err, listas.ErrAcessoNegado)
+	}
+	if err := service.RemoveMembro(ctx, listaID, "editor", dono); !errors.Is(err, listas.ErrAcessoNegado) {
+		t.Fatalf("editor removendo o dono = %v, esperado %v", err, listas.ErrAcessoNegado)
+	}
+
+	membro, err := repo.GetMembro(ctx, listaID, dono)
+	if err != nil {
+		t.Fatalf("GetMembro: %v", err)
+	}
+	if membro == nil || membro.Papel != listas.PapelDono {
+		t.Fatalf("dono = %+v, esperado continuar dono da lista", membro)
+	}
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+)
+
+// DeadLetters dá acesso aos eventos de preço que falharam mesmo depois das novas tentativas
+type DeadLetters interface {
+	// Lista retorna até limite eventos, dos mais antigos para os mais novos, a partir do cursor (vazio para o início)
+	Lista(ctx context.Context, cursor string, limite int) ([]listas.DeadLetter, *string, error)
+	// Busca retorna o evento ou listas.ErrDeadLetterNaoEncontrado
+	Busca(ctx context.Context, id string) (*listas.DeadLetter, error)
+	// Reprocessa devolve o evento ao stream de origem, para ser consumido de novo, e retorna o novo ID
+	Reprocessa(ctx context.Context, id string) (string, error)
+	Descarta(ctx context.Context, id string) error
+}
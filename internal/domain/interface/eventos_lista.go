@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+)
+
+// EventosLista distribui as alterações das listas para quem as acompanha em tempo real
+type EventosLista interface {
+	// Publica entrega o evento aos assinantes da lista, em todas as réplicas. Não bloqueia nem falha:
+	// perder um evento não desfaz a alteração, que já foi confirmada.
+	Publica(ctx context.Context, evento listas.EventoLista)
+	// Assina passa a receber os eventos da lista. O canal é fechado ao cancelar a assinatura,
+	// no desligamento do serviço ou se o assinante não der conta de consumir os eventos.
+	Assina(listaID int64) (<-chan listas.EventoLista, func())
+}
@@ -1,20 +1,80 @@
 package interfaces
 
-import "comparei-servico-listas/internal/domain/listas"
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"time"
+)
 
 type ListaRepository interface {
-	HasOpenList(userID string) (bool, error)
-	Create(lista *listas.Lista) (int64, error)
+	// WithTx executa fn numa transação: todas as chamadas feitas no repo recebido são confirmadas
+	// juntas se fn retornar nil, ou desfeitas se retornar erro
+	WithTx(ctx context.Context, fn func(repo ListaRepository) error) error
 
-	GetByID(id int64, userID string) (*listas.Lista, error)
-	FinalizaLista(listaID int64, userID string) error
-	GetAll(userID string) ([]*listas.Lista, error)
-	Update(lista *listas.Lista) error
+	// TravaUsuario, dentro de WithTx, faz as transações do mesmo usuário esperarem umas pelas outras até o commit.
+	// Deve vir antes de CountOpenLists, para que duas criações simultâneas não passem juntas pelo limite.
+	TravaUsuario(ctx context.Context, userID string) error
+	CountOpenLists(ctx context.Context, userID string) (int, error)
+	// GetOpenList retorna a lista ABERTA mais recente criada pelo usuário, com os itens, ou nil se não houver.
+	// É a que conta no limite de listas abertas.
+	GetOpenList(ctx context.Context, userID string) (*listas.Lista, error)
+	// GetOpenListMembro retorna a lista ABERTA mais recente em que o usuário é membro ativo, dele ou compartilhada,
+	// com os itens, ou nil se não houver
+	GetOpenListMembro(ctx context.Context, userID string) (*listas.Lista, error)
+	Create(ctx context.Context, lista *listas.Lista) (int64, error)
 
-	AddItem(item *listas.ItemLista) error
-	RemoveItem(itemID int64) error
-	UpdateItem(item *listas.ItemLista) error
-	GetItem(itemID int64) (*listas.ItemLista, error)
+	// FindByID busca a lista com os itens, sem checar acesso: quem pode ver a lista é decidido por GetMembro
+	FindByID(ctx context.Context, id int64) (*listas.Lista, error)
+	// UpdateStatus só altera a lista se ela ainda estiver no status "de" (evita corrida entre transições)
+	UpdateStatus(ctx context.Context, lista *listas.Lista, de listas.StatusLista) error
+	// GetAll retorna uma página de resumos das listas em que o usuário é membro ativo, conforme o filtro já normalizado
+	GetAll(ctx context.Context, userID string, filtro listas.FiltroListas) (*listas.PaginaListas, error)
+	Update(ctx context.Context, lista *listas.Lista) error
 
-	UpdatePriceInOpenLists(produtoID int64, mercadoID int64, novoPreco float64) error
+	InsertAuditoria(ctx context.Context, auditoria *listas.Auditoria) error
+
+	AddMembro(ctx context.Context, membro *listas.Membro) error
+	// GetMembro retorna a participação do usuário na lista (ativa ou pendente) ou nil se não houver
+	GetMembro(ctx context.Context, listaID int64, userID string) (*listas.Membro, error)
+	GetMembros(ctx context.Context, listaID int64) ([]listas.Membro, error)
+	GetConvitesPendentes(ctx context.Context, userID string) ([]listas.Membro, error)
+	AceitaConvite(ctx context.Context, listaID int64, userID string, em time.Time) error
+	RemoveMembro(ctx context.Context, listaID int64, userID string) error
+
+	// CreateLink grava o link e preenche ID e CreatedAt, usados na assinatura do token
+	CreateLink(ctx context.Context, link *listas.LinkCompartilhamento) error
+	GetLink(ctx context.Context, id int64) (*listas.LinkCompartilhamento, error)
+	// GetLinksAtivos retorna os links não revogados e ainda não expirados da lista
+	GetLinksAtivos(ctx context.Context, listaID int64, agora time.Time) ([]listas.LinkCompartilhamento, error)
+	RevogaLink(ctx context.Context, id int64, em time.Time) error
+
+	AddItem(ctx context.Context, item *listas.ItemLista) error
+	RemoveItem(ctx context.Context, itemID int64) error
+	UpdateItem(ctx context.Context, item *listas.ItemLista) error
+	GetItem(ctx context.Context, itemID int64) (*listas.ItemLista, error)
+
+	// UpdatePriceInOpenLists atualiza o preço nas listas ABERTAS e retorna os IDs das listas afetadas.
+	// Só deve ser chamada, na mesma transação, depois de SavePrecoAtual aceitar o preço.
+	UpdatePriceInOpenLists(ctx context.Context, produtoID int64, mercadoID int64, novoPreco float64) ([]int64, error)
+	// SavePrecoAtual guarda o preço do produto no mercado, alterado no Promer em modificadoEm. Retorna false, sem gravar,
+	// se o preço guardado vier de uma alteração mais recente (evento atrasado ou reprocessado da dead-letter).
+	// Dentro de WithTx o preço fica travado até o fim da transação.
+	SavePrecoAtual(ctx context.Context, produtoID int64, mercadoID int64, preco float64, modificadoEm time.Time) (bool, error)
+	GetPrecoAtual(ctx context.Context, produtoID int64, mercadoID int64) (*float64, error)
+
+	// InsertOutbox grava o evento de integração; chamado dentro de WithTx, junto com a alteração que o gerou.
+	// Se a outbox já tem uma mensagem com o mesmo EventoID, nada é gravado e msg recebe o ID da existente.
+	InsertOutbox(ctx context.Context, msg *listas.MensagemOutbox) error
+	// GetOutboxPendentes retorna as mensagens não enviadas cuja próxima tentativa já chegou, das mais antigas para as
+	// mais novas. Dentro de WithTx as linhas ficam travadas, e as já travadas por outra réplica são puladas.
+	GetOutboxPendentes(ctx context.Context, agora time.Time, limite int) ([]listas.MensagemOutbox, error)
+	// ReservaOutbox adia a mensagem para "ate" sem contar tentativa: enquanto o relay a publica, fora da transação
+	// que a leu, nem ele nem as outras réplicas a pegam de novo
+	ReservaOutbox(ctx context.Context, id int64, ate time.Time) error
+	MarcaOutboxEnviada(ctx context.Context, id int64, em time.Time) error
+	// MarcaOutboxFalha conta a tentativa, guarda o erro e adia a mensagem para proximaTentativa
+	MarcaOutboxFalha(ctx context.Context, id int64, erro string, proximaTentativa time.Time) error
+	GetBacklogOutbox(ctx context.Context) (*listas.BacklogOutbox, error)
+	// RemoveOutboxEnviadas apaga as mensagens enviadas antes de "antes" e retorna quantas foram removidas
+	RemoveOutboxEnviadas(ctx context.Context, antes time.Time) (int64, error)
 }
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+)
+
+// Publisher envia os eventos de integração aos outros serviços do Comparei.
+// O service não publica direto: grava na outbox, e o relay usa o Publisher.
+type Publisher interface {
+	Publica(ctx context.Context, envelope *listas.Envelope) error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import "comparei-servico-listas/internal/domain/listas"
+
+// TokenCompartilhamento assina e valida os tokens dos links públicos de listas
+type TokenCompartilhamento interface {
+	// Gera o token do link, válido até link.ExpiraEm
+	Gera(link *listas.LinkCompartilhamento) (string, error)
+	// Valida confere assinatura e validade e retorna o ID do link; falhas são listas.ErrLinkInvalido
+	Valida(token string) (int64, error)
+}
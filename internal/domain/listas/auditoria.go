@@ -0,0 +1,18 @@
+package listas
+
+import "time"
+
+const (
+	AcaoReabertura = "REABERTURA"
+)
+
+// Auditoria registra uma ação relevante feita pelo usuário sobre a lista
+type Auditoria struct {
+	ID             int64       `json:"id"`
+	ListaID        int64       `json:"lista_id"`
+	UserID         string      `json:"user_id"`
+	Acao           string      `json:"acao"`
+	StatusAnterior StatusLista `json:"status_anterior"`
+	StatusNovo     StatusLista `json:"status_novo"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package listas
+
+import "time"
+
+// DeadLetter é um evento recebido que não pôde ser processado, guardado com o conteúdo original
+// para ser analisado e reprocessado depois
+type DeadLetter struct {
+	ID         string    `json:"id"`
+	Payload    string    `json:"payload"`
+	Erro       string    `json:"erro"`
+	Origem     string    `json:"origem"` // stream de onde o evento veio
+	IDOrigem   string    `json:"id_origem"`
+	Tentativas int       `json:"tentativas"`
+	FalhouEm   time.Time `json:"falhou_em"`
+}
@@ -0,0 +1,43 @@
+package listas
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Erros de domínio: a camada HTTP usa errors.Is para escolher o status da resposta
+var (
+	ErrListaNaoEncontrada = errors.New("lista não encontrada")
+	ErrTransicaoInvalida  = errors.New("transição de status inválida")
+
+	ErrItemNaoEncontrado = errors.New("item não encontrado")
+	ErrAcessoNegado      = errors.New("acesso negado")
+	ErrListaNaoEditavel  = errors.New("não é possível editar uma lista fechada")
+
+	ErrFiltroInvalido = errors.New("filtro inválido")
+
+	ErrMembroNaoEncontrado = errors.New("membro ou convite não encontrado")
+	ErrMembroExistente     = errors.New("usuário já é membro ou já foi convidado para a lista")
+	ErrPapelInvalido       = errors.New("papel inválido: use editor ou viewer")
+
+	// ErrLinkInvalido cobre token malformado, expirado ou revogado, sem diferenciar para quem acessa
+	ErrLinkInvalido               = errors.New("link de compartilhamento inválido ou expirado")
+	ErrLinkNaoEncontrado          = errors.New("link de compartilhamento não encontrado")
+	ErrCompartilhamentoDesativado = errors.New("compartilhamento por link desativado: SHARE_TOKEN_SECRET não configurado")
+
+	ErrDeadLetterNaoEncontrado = errors.New("evento não encontrado na fila de dead-letter")
+)
+
+// LimiteListasAbertasError indica que o usuário já atingiu o limite de listas ABERTAS do seu plano.
+// ListaID é a lista aberta mais recente, para o cliente poder direcionar o usuário a ela.
+type LimiteListasAbertasError struct {
+	Limite  int
+	ListaID int64
+}
+
+func (e *LimiteListasAbertasError) Error() string {
+	if e.Limite == 1 {
+		return fmt.Sprintf("usuário já possui uma lista em aberto (lista %d)", e.ListaID)
+	}
+	return fmt.Sprintf("usuário já possui %d listas em aberto, o limite do plano", e.Limite)
+}
@@ -0,0 +1,44 @@
+package listas
+
+import "time"
+
+// TipoEvento identifica a alteração enviada a quem acompanha a lista em tempo real
+type TipoEvento string
+
+const (
+	EventoItemAdicionado TipoEvento = "item_adicionado"
+	EventoItemAlterado   TipoEvento = "item_alterado"
+	EventoItemRemovido   TipoEvento = "item_removido"
+	EventoItemMarcado    TipoEvento = "item_marcado"
+	EventoPrecoAlterado  TipoEvento = "preco_alterado"
+	EventoStatusAlterado TipoEvento = "status_alterado"
+)
+
+// EventoLista descreve uma alteração já confirmada no banco. Sempre traz os totais atualizados da lista,
+// e os demais campos conforme o tipo: o item afetado, o novo status ou o novo preço de um produto.
+type EventoLista struct {
+	Tipo          TipoEvento  `json:"tipo"`
+	ListaID       int64       `json:"lista_id"`
+	UserID        string      `json:"user_id,omitempty"` // quem alterou; vazio nas atualizações de preço
+	Item          *ItemLista  `json:"item,omitempty"`
+	ItemID        int64       `json:"item_id,omitempty"`
+	Status        StatusLista `json:"status,omitempty"`
+	ProdutoID     int64       `json:"produto_id,omitempty"`
+	MercadoID     int64       `json:"mercado_id,omitempty"`
+	Preco         *float64    `json:"preco,omitempty"`
+	TotalPrevisto float64     `json:"total_previsto"`
+	TotalFinal    float64     `json:"total_final"`
+	OcorridoEm    time.Time   `json:"ocorrido_em"`
+}
+
+// NovoEvento monta o evento com os dados comuns a todos os tipos, a partir da lista já atualizada
+func NovoEvento(tipo TipoEvento, l *Lista, userID string) EventoLista {
+	return EventoLista{
+		Tipo:          tipo,
+		ListaID:       l.ID,
+		UserID:        userID,
+		TotalPrevisto: l.TotalPrevisto,
+		TotalFinal:    l.TotalFinal,
+		OcorridoEm:    time.Now(),
+	}
+}
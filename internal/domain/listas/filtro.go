@@ -0,0 +1,149 @@
+package listas
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	LimitePadrao = 20
+	LimiteMaximo = 100
+)
+
+// CampoOrdenacao é a coluna usada para ordenar (e paginar) a listagem
+type CampoOrdenacao string
+
+const (
+	OrdenaCriacao     CampoOrdenacao = "created_at"
+	OrdenaAtualizacao CampoOrdenacao = "updated_at"
+	OrdenaNome        CampoOrdenacao = "nome"
+)
+
+// Ordenacao sempre desempata pelo ID, no mesmo sentido, para que o cursor seja estável
+type Ordenacao struct {
+	Campo CampoOrdenacao
+	Desc  bool
+}
+
+// OrdenacaoPadrao mantém a ordem original da listagem: mais recentes primeiro
+var OrdenacaoPadrao = Ordenacao{Campo: OrdenaCriacao, Desc: true}
+
+// ParseOrdenacao lê o formato da query string: "nome", "-created_at" (o "-" indica decrescente)
+func ParseOrdenacao(s string) (Ordenacao, error) {
+	if s == "" {
+		return OrdenacaoPadrao, nil
+	}
+
+	o := Ordenacao{Campo: CampoOrdenacao(strings.TrimPrefix(s, "-")), Desc: strings.HasPrefix(s, "-")}
+	switch o.Campo {
+	case OrdenaCriacao, OrdenaAtualizacao, OrdenaNome:
+		return o, nil
+	}
+	return Ordenacao{}, fmt.Errorf("%w: ordenação %q", ErrFiltroInvalido, s)
+}
+
+// FiltroListas descreve uma página da listagem de listas do usuário
+type FiltroListas struct {
+	Status    *StatusLista
+	Nome      string     // trecho do nome, sem diferenciar maiúsculas
+	CriadaDe  *time.Time // inclusivo
+	CriadaAte *time.Time // exclusivo
+
+	Ordenacao Ordenacao
+	Limite    int
+	Cursor    *Cursor // nil para a primeira página
+}
+
+// Normaliza valida o filtro e preenche os valores padrão
+func (f *FiltroListas) Normaliza() error {
+	if f.Status != nil {
+		if _, ok := transicoes[*f.Status]; !ok {
+			return fmt.Errorf("%w: status %q", ErrFiltroInvalido, *f.Status)
+		}
+	}
+	if f.CriadaDe != nil && f.CriadaAte != nil && !f.CriadaDe.Before(*f.CriadaAte) {
+		return fmt.Errorf("%w: intervalo de datas vazio", ErrFiltroInvalido)
+	}
+	if f.Ordenacao.Campo == "" {
+		f.Ordenacao = OrdenacaoPadrao
+	}
+
+	switch {
+	case f.Limite <= 0:
+		f.Limite = LimitePadrao
+	case f.Limite > LimiteMaximo:
+		f.Limite = LimiteMaximo
+	}
+	return nil
+}
+
+// PaginaListas é o resultado paginado: Total conta todas as listas que atendem ao filtro
+type PaginaListas struct {
+	Listas     []*ResumoLista `json:"listas"`
+	NextCursor *string        `json:"next_cursor"`
+	Total      int            `json:"total"`
+}
+
+// NovaPagina recebe até Limite+1 listas já ordenadas: a sobra indica que existe uma próxima página
+func NovaPagina(listasArr []*ResumoLista, total int, f FiltroListas) *PaginaListas {
+	pagina := &PaginaListas{Listas: listasArr, Total: total}
+	if len(listasArr) > f.Limite {
+		pagina.Listas = listasArr[:f.Limite]
+		proximo := CursorDe(pagina.Listas[f.Limite-1], f.Ordenacao).Encode()
+		pagina.NextCursor = &proximo
+	}
+	if pagina.Listas == nil {
+		pagina.Listas = []*ResumoLista{}
+	}
+	return pagina
+}
+
+// Cursor guarda a posição da última lista retornada: o valor do campo de ordenação e o ID
+type Cursor struct {
+	Valor string `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+// CursorDe monta o cursor que aponta para logo depois de l na ordenação o
+func CursorDe(l *ResumoLista, o Ordenacao) *Cursor {
+	c := &Cursor{ID: l.ID}
+	switch o.Campo {
+	case OrdenaCriacao:
+		c.Valor = l.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case OrdenaAtualizacao:
+		c.Valor = l.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case OrdenaNome:
+		c.Valor = l.Nome
+	}
+	return c
+}
+
+// Data interpreta o valor do cursor quando a ordenação é por um campo de data
+func (c *Cursor) Data() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Valor)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: cursor", ErrFiltroInvalido)
+	}
+	return t, nil
+}
+
+// Encode gera o cursor opaco devolvido ao cliente
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cursor", ErrFiltroInvalido)
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(b, c); err != nil || c.ID == 0 {
+		return nil, fmt.Errorf("%w: cursor", ErrFiltroInvalido)
+	}
+	return c, nil
+}
@@ -0,0 +1,185 @@
+package listas
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventoIntegracao é um evento enviado aos outros serviços do Comparei (produtos, logs).
+// Diferente de EventoLista, que só alimenta os clientes conectados, o formato destes eventos é um contrato.
+type EventoIntegracao interface {
+	TipoIntegracao() string
+}
+
+// EventoIdempotente é o evento que pode ser gerado de novo pela mesma causa (ex.: desmarcar e marcar o item).
+// Eventos com a mesma chave recebem o mesmo ID no envelope: a outbox grava só o primeiro e o consumidor
+// descarta os que ainda chegarem repetidos.
+type EventoIdempotente interface {
+	EventoIntegracao
+	ChaveIdempotencia() string
+}
+
+const (
+	TipoItemComprado    = "lista.item_comprado"
+	TipoListaCriada     = "lista.criada"
+	TipoListaFinalizada = "lista.finalizada"
+	TipoLogEvento       = "log.evento"
+)
+
+// Ações registradas no serviço de logs, além das de Auditoria
+const (
+	AcaoItemComprado = "ITEM_COMPRADO"
+)
+
+// ItemComprado confirma que o produto foi comprado pelo preço informado, servindo de sinal de preço para o serviço de produtos
+type ItemComprado struct {
+	ListaID       int64   `json:"lista_id"`
+	ItemID        int64   `json:"item_id"`
+	UserID        string  `json:"user_id"`
+	ProdutoID     int64   `json:"produto_id"`
+	MercadoID     *int64  `json:"mercado_id"`
+	PrecoUnitario float64 `json:"preco_unitario"`
+	Quantidade    float64 `json:"quantidade"`
+}
+
+func (ItemComprado) TipoIntegracao() string { return TipoItemComprado }
+
+// ChaveIdempotencia identifica a compra: marcar de novo o mesmo item com o mesmo preço, mercado e quantidade
+// não é uma nova compra
+func (e ItemComprado) ChaveIdempotencia() string {
+	mercado := "-"
+	if e.MercadoID != nil {
+		mercado = fmt.Sprint(*e.MercadoID)
+	}
+	return fmt.Sprintf("%d:%d:%d:%s:%g:%g", e.ListaID, e.ItemID, e.ProdutoID, mercado, e.PrecoUnitario, e.Quantidade)
+}
+
+type ListaCriada struct {
+	ListaID     int64  `json:"lista_id"`
+	UserID      string `json:"user_id"`
+	Nome        string `json:"nome"`
+	DuplicadaDe *int64 `json:"duplicada_de"` // lista de origem, quando criada por DuplicaLista
+	TotalItens  int    `json:"total_itens"`
+}
+
+func (ListaCriada) TipoIntegracao() string { return TipoListaCriada }
+
+type ListaFinalizada struct {
+	ListaID        int64     `json:"lista_id"`
+	UserID         string    `json:"user_id"` // quem finalizou, que pode não ser o dono
+	TotalPrevisto  float64   `json:"total_previsto"`
+	TotalFinal     float64   `json:"total_final"`
+	ItensComprados int       `json:"itens_comprados"`
+	FinalizadaEm   time.Time `json:"finalizada_em"`
+}
+
+func (ListaFinalizada) TipoIntegracao() string { return TipoListaFinalizada }
+
+// LogEvento é o registro de auditoria enviado ao serviço de logs
+type LogEvento struct {
+	UserID    string `json:"user_id"`
+	ListaID   int64  `json:"lista_id"`
+	Acao      string `json:"acao"`
+	Descricao string `json:"descricao"`
+	// Chave, quando preenchida, faz do registro um EventoIdempotente: repetições da mesma ação não são registradas de novo
+	Chave string `json:"-"`
+}
+
+func (LogEvento) TipoIntegracao() string { return TipoLogEvento }
+
+func (e LogEvento) ChaveIdempotencia() string { return e.Chave }
+
+// VersaoEnvelope muda quando o formato do envelope (não o dos dados) deixar de ser compatível
+const VersaoEnvelope = 1
+
+const origemServico = "comparei-servico-listas"
+
+// Envelope é o formato comum de todos os eventos de integração: Dados depende de Tipo
+type Envelope struct {
+	Versao     int             `json:"versao"`
+	ID         string          `json:"id"` // permite ao consumidor descartar duplicados
+	Tipo       string          `json:"tipo"`
+	Origem     string          `json:"origem"`
+	OcorridoEm time.Time       `json:"ocorrido_em"`
+	Dados      json.RawMessage `json:"dados"`
+}
+
+func NovoEnvelope(evento EventoIntegracao) (*Envelope, error) {
+	dados, err := json.Marshal(evento)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := idEnvelope(evento)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Versao:     VersaoEnvelope,
+		ID:         id,
+		Tipo:       evento.TipoIntegracao(),
+		Origem:     origemServico,
+		OcorridoEm: time.Now().UTC(),
+		Dados:      dados,
+	}, nil
+}
+
+// idEnvelope deriva o ID da chave de idempotência do evento, quando houver; senão, sorteia um
+func idEnvelope(evento EventoIntegracao) (string, error) {
+	if e, ok := evento.(EventoIdempotente); ok && e.ChaveIdempotencia() != "" {
+		soma := sha256.Sum256([]byte(e.TipoIntegracao() + "|" + e.ChaveIdempotencia()))
+		return hex.EncodeToString(soma[:16]), nil
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// MensagemOutbox é um evento gravado na mesma transação da alteração que o originou,
+// à espera de ser publicado. Payload é o Envelope em JSON.
+type MensagemOutbox struct {
+	ID               int64
+	EventoID         string
+	Tipo             string
+	Payload          string
+	Tentativas       int
+	UltimoErro       *string
+	ProximaTentativa time.Time
+	EnviadoEm        *time.Time
+	CreatedAt        time.Time
+}
+
+func NovaMensagemOutbox(evento EventoIntegracao) (*MensagemOutbox, error) {
+	envelope, err := NovoEnvelope(evento)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return &MensagemOutbox{EventoID: envelope.ID, Tipo: envelope.Tipo, Payload: string(payload)}, nil
+}
+
+// Envelope decodifica o evento guardado na mensagem
+func (m *MensagemOutbox) Envelope() (*Envelope, error) {
+	envelope := &Envelope{}
+	if err := json.Unmarshal([]byte(m.Payload), envelope); err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+// BacklogOutbox resume as mensagens ainda não publicadas
+type BacklogOutbox struct {
+	Pendentes  int
+	MaisAntiga *time.Time
+}
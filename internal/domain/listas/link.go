@@ -0,0 +1,65 @@
+package listas
+
+import "time"
+
+const (
+	ValidadeLinkPadrao = 72 * time.Hour
+	ValidadeLinkMaxima = 30 * 24 * time.Hour
+)
+
+// LinkCompartilhamento é um link público, só de leitura, para quem não tem conta.
+// O token entregue ao usuário é assinado e aponta para este registro, que permite revogá-lo.
+type LinkCompartilhamento struct {
+	ID         int64      `json:"id"`
+	ListaID    int64      `json:"lista_id"`
+	CriadoPor  string     `json:"-"`
+	ExpiraEm   time.Time  `json:"expira_em"`
+	RevogadoEm *time.Time `json:"revogado_em"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Valido indica se o link ainda pode ser usado no instante informado
+func (l *LinkCompartilhamento) Valido(agora time.Time) bool {
+	return l.RevogadoEm == nil && agora.Before(l.ExpiraEm)
+}
+
+// ListaPublica é a visão sanitizada exposta pelo link: sem IDs de usuários nem de registros
+type ListaPublica struct {
+	Nome          string        `json:"nome"`
+	Status        StatusLista   `json:"status"`
+	TotalPrevisto float64       `json:"total_previsto"`
+	TotalFinal    float64       `json:"total_final"`
+	FinalizadaEm  *time.Time    `json:"finalizada_em"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Itens         []ItemPublico `json:"itens"`
+}
+
+type ItemPublico struct {
+	ProdutoID     int64   `json:"produto_id"`
+	MercadoID     *int64  `json:"mercado_id"`
+	Quantidade    float64 `json:"quantidade"`
+	PrecoUnitario float64 `json:"preco_unitario"`
+	Checked       bool    `json:"checked"`
+}
+
+func VisaoPublica(l *Lista) *ListaPublica {
+	v := &ListaPublica{
+		Nome:          l.Nome,
+		Status:        l.Status,
+		TotalPrevisto: l.TotalPrevisto,
+		TotalFinal:    l.TotalFinal,
+		FinalizadaEm:  l.FinalizadaEm,
+		UpdatedAt:     l.UpdatedAt,
+		Itens:         []ItemPublico{},
+	}
+	for _, item := range l.Itens {
+		v.Itens = append(v.Itens, ItemPublico{
+			ProdutoID:     item.ProdutoID,
+			MercadoID:     item.MercadoID,
+			Quantidade:    item.Quantidade,
+			PrecoUnitario: item.PrecoUnitario,
+			Checked:       item.Checked,
+		})
+	}
+	return v
+}
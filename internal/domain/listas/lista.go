@@ -1,6 +1,9 @@
 package listas
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type StatusLista string
 
@@ -18,6 +21,7 @@ type Lista struct {
 	TotalPrevisto float64     `json:"total_previsto"`
 	TotalFinal    float64     `json:"total_final"`
 	Itens         []ItemLista `json:"itens"`
+	FinalizadaEm  *time.Time  `json:"finalizada_em"`
 	CreatedAt     time.Time   `json:"created_at"`
 	UpdatedAt     time.Time   `json:"updated_at"`
 }
@@ -30,4 +34,106 @@ type ItemLista struct {
 	Quantidade    float64 `json:"quantidade"`
 	PrecoUnitario float64 `json:"preco_unitario"`
 	Checked       bool    `json:"checked"`
+	CheckedBy     *string `json:"checked_by"` // quem marcou o item, em listas compartilhadas
+
+	// Snapshot gravado na finalização: o que foi efetivamente pago e onde
+	PrecoPago     *float64 `json:"preco_pago"`
+	MercadoPagoID *int64   `json:"mercado_pago_id"`
+}
+
+// AlteracaoItem é uma edição parcial de ItemLista: campos nil mantêm o valor atual
+type AlteracaoItem struct {
+	Quantidade    *float64
+	PrecoUnitario *float64
+	MercadoID     *int64
+}
+
+// Aplica copia para o item apenas os campos informados na alteração
+func (i *ItemLista) Aplica(alteracao AlteracaoItem) {
+	if alteracao.Quantidade != nil {
+		i.Quantidade = *alteracao.Quantidade
+	}
+	if alteracao.PrecoUnitario != nil {
+		i.PrecoUnitario = *alteracao.PrecoUnitario
+	}
+	if alteracao.MercadoID != nil {
+		i.MercadoID = alteracao.MercadoID
+	}
+}
+
+// Marca marca ou desmarca o item, registrando quem o marcou
+func (i *ItemLista) Marca(checked bool, userID string) {
+	i.Checked = checked
+	if checked {
+		i.CheckedBy = &userID
+	} else {
+		i.CheckedBy = nil
+	}
+}
+
+// Finaliza fecha a lista congelando, para cada item marcado, o preço e o mercado pagos.
+// Depois disso o total final passa a ser calculado sobre o snapshot e não muda mais.
+func (l *Lista) Finaliza(em time.Time) error {
+	if err := l.MudaStatus(StatusFechada); err != nil {
+		return err
+	}
+
+	for i := range l.Itens {
+		item := &l.Itens[i]
+		if !item.Checked {
+			continue
+		}
+		preco := item.PrecoUnitario
+		item.PrecoPago = &preco
+		item.MercadoPagoID = item.MercadoID
+	}
+
+	l.RecalculaTotais()
+	l.FinalizadaEm = &em
+	return nil
+}
+
+// Reabre devolve a lista para ABERTA, descartando o snapshot da finalização.
+// Um novo snapshot é gerado quando a lista for finalizada de novo.
+func (l *Lista) Reabre() error {
+	if err := l.MudaStatus(StatusAberta); err != nil {
+		return err
+	}
+
+	for i := range l.Itens {
+		l.Itens[i].PrecoPago = nil
+		l.Itens[i].MercadoPagoID = nil
+	}
+
+	l.RecalculaTotais()
+	l.FinalizadaEm = nil
+	return nil
+}
+
+// RecalculaTotais soma o total previsto (todos os itens) e o total final (apenas itens marcados).
+// Os itens considerados são os carregados em Itens, que já vêm sem os removidos.
+func (l *Lista) RecalculaTotais() {
+	var previsto, final float64
+	for _, item := range l.Itens {
+		previsto += item.Quantidade * item.PrecoUnitario
+		if item.Checked {
+			final += item.Quantidade * item.precoFinal()
+		}
+	}
+
+	l.TotalPrevisto = arredonda(previsto)
+	l.TotalFinal = arredonda(final)
+}
+
+// precoFinal usa o preço pago congelado na finalização, se houver
+func (i ItemLista) precoFinal() float64 {
+	if i.PrecoPago != nil {
+		return *i.PrecoPago
+	}
+	return i.PrecoUnitario
+}
+
+// arredonda para centavos, mesma precisão da coluna DECIMAL(10, 2)
+func arredonda(valor float64) float64 {
+	return math.Round(valor*100) / 100
 }
@@ -0,0 +1,47 @@
+package listas
+
+import "time"
+
+// PapelMembro define o que um participante pode fazer numa lista compartilhada
+type PapelMembro string
+
+const (
+	PapelDono   PapelMembro = "owner"  // criador da lista: gerencia membros, cancela e reabre
+	PapelEditor PapelMembro = "editor" // edita e marca itens, finaliza a compra
+	PapelLeitor PapelMembro = "viewer" // só visualiza
+)
+
+// Membro é a participação de um usuário numa lista. Convites ficam pendentes até serem aceitos.
+type Membro struct {
+	ListaID      int64       `json:"lista_id"`
+	UserID       string      `json:"user_id"`
+	Papel        PapelMembro `json:"papel"`
+	ConvidadoPor string      `json:"convidado_por"`
+	AceitoEm     *time.Time  `json:"aceito_em"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// Ativo indica se o convite já foi aceito; membros pendentes ainda não acessam a lista
+func (m *Membro) Ativo() bool {
+	return m.AceitoEm != nil
+}
+
+// PodeVer vale para qualquer papel
+func (p PapelMembro) PodeVer() bool {
+	return p == PapelDono || p == PapelEditor || p == PapelLeitor
+}
+
+// PodeEditar permite alterar e marcar itens
+func (p PapelMembro) PodeEditar() bool {
+	return p == PapelDono || p == PapelEditor
+}
+
+// PodeGerenciar permite convidar e remover membros e mudar o ciclo de vida da lista (cancelar, reabrir)
+func (p PapelMembro) PodeGerenciar() bool {
+	return p == PapelDono
+}
+
+// Convidavel indica os papéis que podem ser atribuídos por convite: o dono é sempre o criador
+func (p PapelMembro) Convidavel() bool {
+	return p == PapelEditor || p == PapelLeitor
+}
@@ -0,0 +1,19 @@
+package listas
+
+// PoliticaListasAbertas limita quantas listas ABERTAS um usuário pode ter ao mesmo tempo.
+// Um limite 0 significa sem limite.
+type PoliticaListasAbertas struct {
+	MaxPadrao int            // para usuários sem plano ou com plano não listado em PorPlano
+	PorPlano  map[string]int // limite por plano do usuário (claim "plano" do token)
+}
+
+// PoliticaUmaListaAberta é a regra original do serviço: uma lista aberta por usuário
+var PoliticaUmaListaAberta = PoliticaListasAbertas{MaxPadrao: 1}
+
+// Limite retorna o máximo de listas abertas para o plano informado
+func (p PoliticaListasAbertas) Limite(plano string) int {
+	if limite, ok := p.PorPlano[plano]; ok {
+		return limite
+	}
+	return p.MaxPadrao
+}
@@ -0,0 +1,53 @@
+package listas
+
+import "time"
+
+// ResumoLista é a projeção usada na listagem: os dados da lista sem os itens, com a contagem e o progresso
+type ResumoLista struct {
+	ID            int64       `json:"id"`
+	UserID        string      `json:"user_id"`
+	Nome          string      `json:"nome"`
+	Status        StatusLista `json:"status"`
+	TotalPrevisto float64     `json:"total_previsto"`
+	TotalFinal    float64     `json:"total_final"`
+	FinalizadaEm  *time.Time  `json:"finalizada_em"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	Papel         PapelMembro `json:"papel"` // papel do usuário que consultou
+
+	TotalItens          int     `json:"total_itens"`
+	ItensMarcados       int     `json:"itens_marcados"`
+	PercentualConcluido float64 `json:"percentual_concluido"`
+}
+
+// ResumoDe monta o resumo a partir de uma lista com os itens carregados
+func ResumoDe(l *Lista) *ResumoLista {
+	r := &ResumoLista{
+		ID:            l.ID,
+		UserID:        l.UserID,
+		Nome:          l.Nome,
+		Status:        l.Status,
+		TotalPrevisto: l.TotalPrevisto,
+		TotalFinal:    l.TotalFinal,
+		FinalizadaEm:  l.FinalizadaEm,
+		CreatedAt:     l.CreatedAt,
+		UpdatedAt:     l.UpdatedAt,
+		TotalItens:    len(l.Itens),
+	}
+	for _, item := range l.Itens {
+		if item.Checked {
+			r.ItensMarcados++
+		}
+	}
+	r.CalculaProgresso()
+	return r
+}
+
+// CalculaProgresso preenche o percentual de itens marcados (0 para lista sem itens)
+func (r *ResumoLista) CalculaProgresso() {
+	if r.TotalItens == 0 {
+		r.PercentualConcluido = 0
+		return
+	}
+	r.PercentualConcluido = arredonda(float64(r.ItensMarcados) * 100 / float64(r.TotalItens))
+}
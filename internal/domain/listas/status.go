@@ -0,0 +1,29 @@
+package listas
+
+import "fmt"
+
+// transicoes define, para cada status, os destinos permitidos da lista
+var transicoes = map[StatusLista][]StatusLista{
+	StatusAberta:    {StatusFechada, StatusCancelada},
+	StatusFechada:   {StatusAberta},
+	StatusCancelada: {StatusAberta},
+}
+
+// PodeMudarPara indica se a máquina de estados permite ir do status atual para o destino
+func (s StatusLista) PodeMudarPara(destino StatusLista) bool {
+	for _, permitido := range transicoes[s] {
+		if permitido == destino {
+			return true
+		}
+	}
+	return false
+}
+
+// MudaStatus aplica a transição na lista ou retorna ErrTransicaoInvalida
+func (l *Lista) MudaStatus(destino StatusLista) error {
+	if !l.Status.PodeMudarPara(destino) {
+		return fmt.Errorf("%w: %s -> %s", ErrTransicaoInvalida, l.Status, destino)
+	}
+	l.Status = destino
+	return nil
+}
@@ -0,0 +1,50 @@
+package listas
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransicoesDeStatus(t *testing.T) {
+	casos := []struct {
+		de, para StatusLista
+		permite  bool
+	}{
+		{StatusAberta, StatusAberta, false},
+		{StatusAberta, StatusFechada, true},
+		{StatusAberta, StatusCancelada, true},
+		{StatusFechada, StatusAberta, true},
+		{StatusFechada, StatusFechada, false},
+		{StatusFechada, StatusCancelada, false},
+		{StatusCancelada, StatusAberta, true},
+		{StatusCancelada, StatusFechada, false},
+		{StatusCancelada, StatusCancelada, false},
+	}
+
+	for _, c := range casos {
+		t.Run(string(c.de)+"->"+string(c.para), func(t *testing.T) {
+			if got := c.de.PodeMudarPara(c.para); got != c.permite {
+				t.Fatalf("PodeMudarPara = %v, esperado %v", got, c.permite)
+			}
+
+			lista := &Lista{Status: c.de}
+			err := lista.MudaStatus(c.para)
+			if c.permite {
+				if err != nil {
+					t.Fatalf("MudaStatus: erro inesperado: %v", err)
+				}
+				if lista.Status != c.para {
+					t.Fatalf("status = %s, esperado %s", lista.Status, c.para)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrTransicaoInvalida) {
+				t.Fatalf("MudaStatus: erro = %v, esperado ErrTransicaoInvalida", err)
+			}
+			if lista.Status != c.de {
+				t.Fatalf("status mudou para %s numa transição inválida", lista.Status)
+			}
+		})
+	}
+}
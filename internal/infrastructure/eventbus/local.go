@@ -0,0 +1,85 @@
+package eventbus
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"sync"
+)
+
+// Eventos que podem ficar pendentes por assinante antes de ele ser considerado lento
+const bufferAssinante = 64
+
+// Local distribui os eventos entre os assinantes desta réplica
+type Local struct {
+	mu         sync.Mutex
+	assinantes map[int64]map[chan listas.EventoLista]struct{}
+	encerrado  bool
+}
+
+func NewLocal() *Local {
+	return &Local{assinantes: map[int64]map[chan listas.EventoLista]struct{}{}}
+}
+
+func (b *Local) Publica(_ context.Context, evento listas.EventoLista) {
+	b.entrega(evento)
+}
+
+func (b *Local) Assina(listaID int64) (<-chan listas.EventoLista, func()) {
+	ch := make(chan listas.EventoLista, bufferAssinante)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.encerrado {
+		close(ch)
+		return ch, func() {}
+	}
+	if b.assinantes[listaID] == nil {
+		b.assinantes[listaID] = map[chan listas.EventoLista]struct{}{}
+	}
+	b.assinantes[listaID][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(listaID, ch)
+	}
+}
+
+// Encerra fecha todas as assinaturas, para que as conexões abertas terminem no desligamento do serviço
+func (b *Local) Encerra() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.encerrado = true
+	for listaID, chs := range b.assinantes {
+		for ch := range chs {
+			b.remove(listaID, ch)
+		}
+	}
+}
+
+// entrega nunca bloqueia: o assinante com o buffer cheio é desconectado, e ao reconectar
+// o cliente recarrega a lista em vez de seguir com um estado incompleto
+func (b *Local) entrega(evento listas.EventoLista) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.assinantes[evento.ListaID] {
+		select {
+		case ch <- evento:
+		default:
+			b.remove(evento.ListaID, ch)
+		}
+	}
+}
+
+// remove deve ser chamado com o lock; remover duas vezes não tem efeito
+func (b *Local) remove(listaID int64, ch chan listas.EventoLista) {
+	chs := b.assinantes[listaID]
+	if _, ok := chs[ch]; !ok {
+		return
+	}
+	delete(chs, ch)
+	close(ch)
+	if len(chs) == 0 {
+		delete(b.assinantes, listaID)
+	}
+}
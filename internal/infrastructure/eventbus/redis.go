@@ -0,0 +1,77 @@
+package eventbus
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis entrega os eventos aos assinantes locais e os repassa às outras réplicas por um canal de Pub/Sub
+type Redis struct {
+	*Local
+	rdb    *redis.Client
+	canal  string
+	origem string // identifica esta réplica, para não entregar duas vezes os próprios eventos
+}
+
+// mensagem é o que trafega no canal do Redis
+type mensagem struct {
+	Origem string             `json:"origem"`
+	Evento listas.EventoLista `json:"evento"`
+}
+
+func NewRedis(rdb *redis.Client, canal string) *Redis {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return &Redis{Local: NewLocal(), rdb: rdb, canal: canal, origem: hex.EncodeToString(id)}
+}
+
+func (b *Redis) Publica(ctx context.Context, evento listas.EventoLista) {
+	b.entrega(evento)
+
+	payload, err := json.Marshal(mensagem{Origem: b.origem, Evento: evento})
+	if err != nil {
+		log.Println("Erro ao codificar evento da lista:", err)
+		return
+	}
+	if err := b.rdb.Publish(ctx, b.canal, payload).Err(); err != nil {
+		log.Printf("Erro ao repassar evento da lista %d às outras réplicas: %v", evento.ListaID, err)
+	}
+}
+
+// Escuta recebe os eventos publicados pelas outras réplicas até que ctx seja cancelado
+func (b *Redis) Escuta(ctx context.Context) {
+	pubsub := b.rdb.Subscribe(ctx, b.canal)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+
+	for {
+		var msg *redis.Message
+		select {
+		case <-ctx.Done():
+			log.Println("Escuta de eventos das listas encerrada.")
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+			msg = m
+		}
+
+		var m mensagem
+		if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+			log.Println("Erro ao decodificar evento da lista:", err)
+			continue
+		}
+		if m.Origem == b.origem {
+			continue
+		}
+		b.entrega(m.Evento)
+	}
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"comparei-servico-listas/internal/infrastructure/http/dto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler expõe as rotas de operação do serviço, protegidas pela ADMIN_API_KEY
+type AdminHandler struct {
+	DeadLetters interfaces.DeadLetters
+}
+
+func NewAdminHandler(deadLetters interfaces.DeadLetters) *AdminHandler {
+	return &AdminHandler{DeadLetters: deadLetters}
+}
+
+// GetDeadLetters lista os eventos de preço que falharam, dos mais antigos para os mais novos (query: limit, cursor)
+func (h *AdminHandler) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limite := listas.LimitePadrao
+	if v := q.Get("limit"); v != "" {
+		var err error
+		if limite, err = strconv.Atoi(v); err != nil || limite <= 0 {
+			err = fmt.Errorf("%w: limit", listas.ErrFiltroInvalido)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	limite = min(limite, listas.LimiteMaximo)
+
+	deadLetters, proximo, err := h.DeadLetters.Lista(r.Context(), q.Get("cursor"), limite)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.PaginaDeadLettersDTO{DeadLetters: deadLetters, NextCursor: proximo})
+}
+
+func (h *AdminHandler) GetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	deadLetter, err := h.DeadLetters.Busca(r.Context(), vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(deadLetter)
+}
+
+// ReprocessarDeadLetter devolve o evento ao stream de preços; se falhar de novo, ele volta para a dead-letter
+func (h *AdminHandler) ReprocessarDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, err := h.DeadLetters.Reprocessa(r.Context(), vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(dto.ReprocessamentoDTO{ID: id})
+}
+
+func (h *AdminHandler) DescartarDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.DeadLetters.Descarta(r.Context(), vars["id"]); err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,9 +1,19 @@
 package dto
 
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"time"
+)
+
 type CreateListaDTO struct {
 	Nome string `json:"nome"`
 }
 
+// DuplicaListaDTO é opcional: sem nome, a cópia mantém o nome da lista original
+type DuplicaListaDTO struct {
+	Nome string `json:"nome"`
+}
+
 type AddItemDTO struct {
 	ProdutoID     int64   `json:"produto_id"`
 	MercadoID     *int64  `json:"mercado_id"`
@@ -11,6 +21,40 @@ type AddItemDTO struct {
 	PrecoUnitario float64 `json:"preco_unitario"`
 }
 
+// UpdateItemDTO é uma edição parcial: só os campos enviados são alterados
+type UpdateItemDTO struct {
+	Quantidade    *float64 `json:"quantidade"`
+	PrecoUnitario *float64 `json:"preco_unitario"`
+	MercadoID     *int64   `json:"mercado_id"`
+}
+
 type ToggleItemDTO struct {
 	Checked bool `json:"checked"`
 }
+
+// ConviteDTO convida um usuário para a lista com o papel "editor" ou "viewer"
+type ConviteDTO struct {
+	UserID string `json:"user_id"`
+	Papel  string `json:"papel"`
+}
+
+// CompartilharDTO é opcional: sem validade_horas, o link vale por 72h (máximo de 30 dias)
+type CompartilharDTO struct {
+	ValidadeHoras int `json:"validade_horas"`
+}
+
+type LinkCompartilhamentoDTO struct {
+	ID       int64     `json:"id"`
+	Token    string    `json:"token"`
+	URL      string    `json:"url"`
+	ExpiraEm time.Time `json:"expira_em"`
+}
+
+type PaginaDeadLettersDTO struct {
+	DeadLetters []listas.DeadLetter `json:"dead_letters"`
+	NextCursor  *string             `json:"next_cursor"`
+}
+
+type ReprocessamentoDTO struct {
+	ID string `json:"id"` // ID do evento no stream de origem
+}
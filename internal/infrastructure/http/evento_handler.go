@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Intervalo dos comentários de keep-alive, que também revalidam se o usuário continua membro da lista
+const intervaloHeartbeat = 25 * time.Second
+
+// EventosLista mantém a conexão aberta (Server-Sent Events) e envia as alterações da lista
+// até o cliente desconectar. Se a conexão cair, o cliente deve recarregar a lista ao reconectar.
+func (h *ListaHandler) EventosLista(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+
+	eventos, cancela, err := h.Service.AssinaEventos(r.Context(), listaID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+	defer cancela()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // desativa o buffer de proxies como o nginx
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(intervaloHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := h.Service.VerificaMembro(r.Context(), listaID, userID); err != nil {
+				return
+			}
+			fmt.Fprint(w, ": ping\n\n")
+		case evento, ok := <-eventos:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(evento)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evento.Tipo, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"comparei-servico-listas/internal/domain/listas"
 	"comparei-servico-listas/internal/infrastructure/http/dto"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/gorilla/mux"
@@ -30,12 +35,50 @@ func sendErrorResponse(w http.ResponseWriter, statusCode int, err error, message
 	})
 }
 
+// statusFromError traduz os erros de domínio para o status HTTP; os demais usam o status padrão informado
+func statusFromError(err error, padrao int) int {
+	switch {
+	case errors.Is(err, listas.ErrListaNaoEncontrada), errors.Is(err, listas.ErrItemNaoEncontrado), errors.Is(err, listas.ErrMembroNaoEncontrado),
+		errors.Is(err, listas.ErrLinkInvalido), errors.Is(err, listas.ErrLinkNaoEncontrado), errors.Is(err, listas.ErrDeadLetterNaoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, listas.ErrAcessoNegado):
+		return http.StatusForbidden
+	case errors.Is(err, listas.ErrListaNaoEditavel), errors.Is(err, listas.ErrTransicaoInvalida), errors.Is(err, listas.ErrMembroExistente):
+		return http.StatusConflict
+	case errors.Is(err, listas.ErrFiltroInvalido), errors.Is(err, listas.ErrPapelInvalido):
+		return http.StatusBadRequest
+	case errors.Is(err, listas.ErrCompartilhamentoDesativado):
+		return http.StatusServiceUnavailable
+	}
+	return padrao
+}
+
 func validaToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	claims, err := claimsDoToken(r)
+	if err != nil {
+		return "", err
+	}
+
+	id := claims["id"]
+	return fmt.Sprintf("%v", id), nil
+}
+
+// planoDoToken lê a claim opcional "plano", usada na política de listas abertas; sem ela, vale o limite padrão
+func planoDoToken(r *http.Request) string {
+	claims, err := claimsDoToken(r)
+	if err != nil {
+		return ""
+	}
+	plano, _ := claims["plano"].(string)
+	return plano
+}
+
+func claimsDoToken(r *http.Request) (jwt.MapClaims, error) {
 	secret := os.Getenv("USER_JWT_SECRET")
 
 	tokenString := r.Header.Get("Authorization")
 	if tokenString == "" {
-		return "", fmt.Errorf("Missing token")
+		return nil, fmt.Errorf("Missing token")
 	}
 
 	// Remover o prefixo "Bearer " se existir
@@ -52,16 +95,31 @@ func validaToken(w http.ResponseWriter, r *http.Request) (string, error) {
 	})
 
 	if err != nil || !token.Valid {
-		return "", fmt.Errorf("Invalid token")
+		return nil, fmt.Errorf("Invalid token")
 	}
 
 	// Acessar os dados (claims)
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		id := claims["id"]
-		return fmt.Sprintf("%v", id), nil
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("Erro ao decodificar token")
+}
+
+// sendLimiteAbertas responde 409 com a lista aberta mais recente quando err é LimiteListasAbertasError
+func sendLimiteAbertas(w http.ResponseWriter, err error) bool {
+	var errLimite *listas.LimiteListasAbertasError
+	if !errors.As(err, &errLimite) {
+		return false
 	}
 
-	return "", fmt.Errorf("Erro ao decodificar token")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":    err.Error(),
+		"lista_id": errLimite.ListaID,
+		"limite":   errLimite.Limite,
+	})
+	return true
 }
 
 func (h *ListaHandler) CreateLista(w http.ResponseWriter, r *http.Request) {
@@ -87,14 +145,17 @@ func (h *ListaHandler) CreateLista(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Println("NOVA LISTA: ", novaLista)
 
-	id, err := h.Service.CreateLista(novaLista)
+	id, err := h.Service.CreateLista(r.Context(), novaLista, planoDoToken(r))
+	if sendLimiteAbertas(w, err) {
+		return
+	}
 	if err != nil {
 		fmt.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	lista, err := h.Service.GetByID(userID, id)
+	lista, err := h.Service.GetByID(r.Context(), userID, id)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(lista)
@@ -107,13 +168,82 @@ func (h *ListaHandler) GetListas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	listas, err := h.Service.GetListasUsuario(userID)
+	filtro, err := filtroFromQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pagina, err := h.Service.GetListasUsuario(r.Context(), userID, filtro)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(pagina)
+}
+
+// filtroFromQuery lê os parâmetros de GET /listas: status, nome, criada_de, criada_ate, sort, limit e cursor
+func filtroFromQuery(q url.Values) (listas.FiltroListas, error) {
+	var filtro listas.FiltroListas
+
+	if v := q.Get("status"); v != "" {
+		status := listas.StatusLista(strings.ToUpper(v))
+		filtro.Status = &status
+	}
+	filtro.Nome = q.Get("nome")
+
+	var err error
+	if filtro.CriadaDe, err = parseData(q.Get("criada_de")); err != nil {
+		return filtro, err
+	}
+	if filtro.CriadaAte, err = parseData(q.Get("criada_ate")); err != nil {
+		return filtro, err
+	}
+	if filtro.Ordenacao, err = listas.ParseOrdenacao(q.Get("sort")); err != nil {
+		return filtro, err
+	}
+	if v := q.Get("limit"); v != "" {
+		if filtro.Limite, err = strconv.Atoi(v); err != nil {
+			return filtro, fmt.Errorf("%w: limit", listas.ErrFiltroInvalido)
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		if filtro.Cursor, err = listas.DecodeCursor(v); err != nil {
+			return filtro, err
+		}
+	}
+	return filtro, nil
+}
+
+// parseData aceita RFC 3339 ou só a data (AAAA-MM-DD, meia-noite UTC)
+func parseData(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: data %q", listas.ErrFiltroInvalido, v)
+}
+
+func (h *ListaHandler) GetListaAtual(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	lista, err := h.Service.GetListaAtual(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
 		return
 	}
 
-	json.NewEncoder(w).Encode(listas)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(lista)
 }
 
 func (h *ListaHandler) GetListaByID(w http.ResponseWriter, r *http.Request) {
@@ -126,9 +256,9 @@ func (h *ListaHandler) GetListaByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := strconv.ParseInt(vars["id"], 10, 64)
 
-	lista, err := h.Service.GetByID(userID, id)
+	lista, err := h.Service.GetByID(r.Context(), userID, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -148,9 +278,9 @@ func (h *ListaHandler) FinalizarID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := strconv.ParseInt(vars["id"], 10, 64)
 
-	err := h.Service.FinalizaLista(id, userID)
+	err := h.Service.FinalizaLista(r.Context(), id, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -160,6 +290,87 @@ func (h *ListaHandler) FinalizarID(w http.ResponseWriter, r *http.Request) {
 
 }
 
+func (h *ListaHandler) CancelarID(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	err := h.Service.CancelaLista(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("Lista cancelada com sucesso!")
+}
+
+func (h *ListaHandler) ReabrirID(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	err := h.Service.ReabreLista(r.Context(), id, userID, planoDoToken(r))
+	if sendLimiteAbertas(w, err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("Lista reaberta com sucesso!")
+}
+
+func (h *ListaHandler) DuplicarID(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	// O corpo é opcional
+	var req dto.DuplicaListaDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Erro no payload JSON", http.StatusBadRequest)
+		return
+	}
+
+	novoID, err := h.Service.DuplicaLista(r.Context(), id, userID, planoDoToken(r), req.Nome)
+	if sendLimiteAbertas(w, err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	lista, err := h.Service.GetByID(r.Context(), userID, novoID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(lista)
+}
+
 func (h *ListaHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 	userID, err_token := validaToken(w, r)
 	if err_token != nil {
@@ -185,27 +396,61 @@ func (h *ListaHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 		Checked:       false,
 	}
 
-	if err := h.Service.AddItem(userID, item); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.Service.AddItem(r.Context(), userID, item); err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (h *ListaHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	itemID, _ := strconv.ParseInt(vars["item_id"], 10, 64)
+
+	var req dto.UpdateItemDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro no payload JSON", http.StatusBadRequest)
+		return
+	}
+
+	alteracao := listas.AlteracaoItem{
+		Quantidade:    req.Quantidade,
+		PrecoUnitario: req.PrecoUnitario,
+		MercadoID:     req.MercadoID,
+	}
+
+	item, err := h.Service.UpdateItem(r.Context(), userID, listaID, itemID, alteracao)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(item)
+}
+
 func (h *ListaHandler) DelItem(w http.ResponseWriter, r *http.Request) {
-	_, err_token := validaToken(w, r)
+	userID, err_token := validaToken(w, r)
 	if err_token != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
 		return
 	}
 
 	vars := mux.Vars(r)
-	itemListaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	itemID, _ := strconv.ParseInt(vars["item_id"], 10, 64)
 
-	err := h.Service.RemoveItem(itemListaID)
+	err := h.Service.RemoveItem(r.Context(), userID, listaID, itemID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), statusFromError(err, http.StatusBadRequest))
 		return
 	}
 
@@ -228,9 +473,9 @@ func (h *ListaHandler) CheckItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.Service.ToggleItemCheck(userID, itemID, req.Checked)
+	err := h.Service.ToggleItemCheck(r.Context(), userID, itemID, req.Checked)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), statusFromError(err, http.StatusBadRequest))
 		return
 	}
 
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusFromErrorTransicaoInvalida(t *testing.T) {
+	err := fmt.Errorf("%w: %s -> %s", listas.ErrTransicaoInvalida, listas.StatusFechada, listas.StatusCancelada)
+	if got := statusFromError(err, http.StatusInternalServerError); got != http.StatusConflict {
+		t.Fatalf("status = %d, esperado %d", got, http.StatusConflict)
+	}
+}
+
+func TestSendLimiteAbertas(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := fmt.Errorf("criando lista: %w", &listas.LimiteListasAbertasError{Limite: 1, ListaID: 42})
+	if !sendLimiteAbertas(w, err) {
+		t.Fatal("sendLimiteAbertas não reconheceu LimiteListasAbertasError")
+	}
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, esperado %d", w.Code, http.StatusConflict)
+	}
+
+	var corpo struct {
+		ListaID int64 `json:"lista_id"`
+		Limite  int   `json:"limite"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&corpo); err != nil {
+		t.Fatalf("corpo: %v", err)
+	}
+	if corpo.ListaID != 42 || corpo.Limite != 1 {
+		t.Fatalf("corpo = %+v, esperado lista_id 42 e limite 1", corpo)
+	}
+
+	if sendLimiteAbertas(httptest.NewRecorder(), listas.ErrListaNaoEncontrada) {
+		t.Fatal("sendLimiteAbertas respondeu a outro erro")
+	}
+}
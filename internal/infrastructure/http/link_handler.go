@@ -0,0 +1,97 @@
+package http
+
+import (
+	"comparei-servico-listas/internal/infrastructure/http/dto"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *ListaHandler) Compartilhar(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	// O corpo é opcional: sem validade, vale o padrão
+	var req dto.CompartilharDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Erro no payload JSON", http.StatusBadRequest)
+		return
+	}
+
+	link, token, err := h.Service.CompartilhaLista(r.Context(), listaID, userID, time.Duration(req.ValidadeHoras)*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dto.LinkCompartilhamentoDTO{
+		ID:       link.ID,
+		Token:    token,
+		URL:      "/public/listas/" + token,
+		ExpiraEm: link.ExpiraEm,
+	})
+}
+
+func (h *ListaHandler) GetLinks(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	links, err := h.Service.GetLinks(r.Context(), listaID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(links)
+}
+
+func (h *ListaHandler) RevogarLink(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	linkID, _ := strconv.ParseInt(vars["link_id"], 10, 64)
+
+	if err := h.Service.RevogaLink(r.Context(), listaID, userID, linkID); err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetListaPublica é a rota pública do link: não exige API Key nem token do usuário
+func (h *ListaHandler) GetListaPublica(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	lista, err := h.Service.GetListaPublica(r.Context(), vars["token"])
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	// Listas compartilhadas mudam durante a compra: não guardar em cache
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(lista)
+}
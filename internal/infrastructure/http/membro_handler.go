@@ -0,0 +1,110 @@
+package http
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"comparei-servico-listas/internal/infrastructure/http/dto"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *ListaHandler) GetMembros(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	membros, err := h.Service.GetMembros(r.Context(), listaID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(membros)
+}
+
+func (h *ListaHandler) ConvidarMembro(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	var req dto.ConviteDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro no payload JSON", http.StatusBadRequest)
+		return
+	}
+
+	convite, err := h.Service.ConvidaMembro(r.Context(), listaID, userID, req.UserID, listas.PapelMembro(req.Papel))
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(convite)
+}
+
+func (h *ListaHandler) AceitarConvite(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if err := h.Service.AceitaConvite(r.Context(), listaID, userID); err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("Convite aceito com sucesso!")
+}
+
+// RemoverMembro revoga um membro ou convite; com o próprio user_id, o usuário sai da lista
+func (h *ListaHandler) RemoverMembro(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listaID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if err := h.Service.RemoveMembro(r.Context(), listaID, userID, vars["user_id"]); err != nil {
+		http.Error(w, err.Error(), statusFromError(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ListaHandler) GetConvites(w http.ResponseWriter, r *http.Request) {
+	userID, err_token := validaToken(w, r)
+	if err_token != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err_token, "Erro ao refistrar log")
+		return
+	}
+
+	convites, err := h.Service.GetConvites(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(convites)
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+)
+
+// AdminKeyMiddleware exige o header "adminKey" igual à ADMIN_API_KEY. Sem ADMIN_API_KEY configurada,
+// as rotas administrativas ficam fechadas.
+func AdminKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adminKey := r.Header.Get("adminKey")
+
+		expectedAdminKey := os.Getenv("ADMIN_API_KEY")
+
+		if expectedAdminKey == "" || subtle.ConstantTimeCompare([]byte(adminKey), []byte(expectedAdminKey)) != 1 {
+			http.Error(w, "Acesso negado: chave de administração inválida", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
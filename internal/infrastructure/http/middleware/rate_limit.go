@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter limita as requisições por IP numa janela fixa. Usado nas rotas públicas,
+// que não passam pela API Key nem pelo token do usuário.
+//
+// O IP é o RemoteAddr da conexão. Atrás de um proxy reverso ou load balancer, ele é o do proxy
+// e todos os clientes dividiriam o mesmo limite: passe as redes desses proxies em proxiesConfiaveis
+// para que o IP venha do X-Forwarded-For (ou do X-Real-IP) que eles preenchem.
+type RateLimiter struct {
+	limite            int
+	janela            time.Duration
+	proxiesConfiaveis []*net.IPNet
+
+	mu       sync.Mutex
+	inicio   time.Time
+	contagem map[string]int
+}
+
+func NewRateLimiter(limite int, janela time.Duration, proxiesConfiaveis []*net.IPNet) *RateLimiter {
+	return &RateLimiter{limite: limite, janela: janela, proxiesConfiaveis: proxiesConfiaveis, contagem: map[string]int{}}
+}
+
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if espera, ok := l.permite(l.clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(espera.Seconds())+1))
+			http.Error(w, "Muitas requisições, tente novamente em instantes", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// permite conta a requisição do IP e, quando o limite estourou, retorna quanto falta para a próxima janela
+func (l *RateLimiter) permite(ip string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	agora := time.Now()
+	if agora.Sub(l.inicio) >= l.janela {
+		// Nova janela: zera todos os contadores de uma vez, sem acumular IPs antigos
+		l.inicio = agora
+		l.contagem = map[string]int{}
+	}
+
+	if l.contagem[ip] >= l.limite {
+		return l.janela - agora.Sub(l.inicio), false
+	}
+	l.contagem[ip]++
+	return 0, true
+}
+
+// clientIP só confia nos cabeçalhos de encaminhamento quando a conexão vem de um proxy configurado;
+// do contrário, qualquer cliente escolheria o próprio IP e escaparia do limite.
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	remoto := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remoto); err == nil {
+		remoto = host
+	}
+	if !l.confiavel(net.ParseIP(remoto)) {
+		return remoto
+	}
+
+	// Cada proxy acrescenta à direita o IP de quem o chamou: o primeiro IP, da direita para a esquerda,
+	// que não é de um proxy confiável é o do cliente. O que está à esquerda dele pode ter sido forjado.
+	var encaminhados []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		encaminhados = append(encaminhados, strings.Split(v, ",")...)
+	}
+	for i := len(encaminhados) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(encaminhados[i]))
+		if ip == nil {
+			// Entrada inválida: o que vem antes dela não é confiável
+			break
+		}
+		if !l.confiavel(ip) || i == 0 {
+			return ip.String()
+		}
+	}
+	if len(encaminhados) == 0 {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+			return ip.String()
+		}
+	}
+	return remoto
+}
+
+func (l *RateLimiter) confiavel(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, rede := range l.proxiesConfiaveis {
+		if rede.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	limiter := NewRateLimiter(1, time.Minute, []*net.IPNet{proxies})
+
+	casos := []struct {
+		nome      string
+		remoto    string
+		forwarded []string
+		realIP    string
+		esperado  string
+	}{
+		{nome: "sem proxy", remoto: "203.0.113.7:5000", esperado: "203.0.113.7"},
+		{nome: "cabeçalho de quem não é proxy é ignorado", remoto: "203.0.113.7:5000", forwarded: []string{"198.51.100.1"}, esperado: "203.0.113.7"},
+		{nome: "proxy confiável", remoto: "10.0.0.2:5000", forwarded: []string{"198.51.100.1"}, esperado: "198.51.100.1"},
+		{nome: "IP forjado à esquerda é ignorado", remoto: "10.0.0.2:5000", forwarded: []string{"1.2.3.4, 198.51.100.1"}, esperado: "198.51.100.1"},
+		{nome: "vários proxies confiáveis", remoto: "10.0.0.2:5000", forwarded: []string{"198.51.100.1, 10.0.0.9", "10.0.0.3"}, esperado: "198.51.100.1"},
+		{nome: "só proxies na cadeia", remoto: "10.0.0.2:5000", forwarded: []string{"10.0.0.9"}, esperado: "10.0.0.9"},
+		{nome: "entrada inválida", remoto: "10.0.0.2:5000", forwarded: []string{"lixo"}, esperado: "10.0.0.2"},
+		{nome: "X-Real-IP", remoto: "10.0.0.2:5000", realIP: "198.51.100.1", esperado: "198.51.100.1"},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/public/listas/x", nil)
+			r.RemoteAddr = c.remoto
+			for _, v := range c.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if c.realIP != "" {
+				r.Header.Set("X-Real-IP", c.realIP)
+			}
+
+			if ip := limiter.clientIP(r); ip != c.esperado {
+				t.Errorf("clientIP = %s, esperado %s", ip, c.esperado)
+			}
+		})
+	}
+}
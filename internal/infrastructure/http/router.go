@@ -2,12 +2,15 @@ package http
 
 import (
 	"comparei-servico-listas/internal/infrastructure/http/middleware"
+	"expvar"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(handler *ListaHandler) *mux.Router {
+// NewRouter monta as rotas autenticadas (API Key + token do usuário), as administrativas (API Key + chave de
+// administração) e as públicas, que ficam de fora da API Key e atrás do limite de requisições publicLimiter
+func NewRouter(handler *ListaHandler, admin *AdminHandler, publicLimiter *middleware.RateLimiter) *mux.Router {
 	r := mux.NewRouter()
 
 	// Middleware para JSON
@@ -18,18 +21,52 @@ func NewRouter(handler *ListaHandler) *mux.Router {
 		})
 	})
 
+	// Rotas públicas (links de compartilhamento): registradas antes para não passar pela API Key
+	public := r.PathPrefix("/public").Subrouter()
+	public.Use(publicLimiter.Middleware)
+	public.HandleFunc("/listas/{token}", handler.GetListaPublica).Methods("GET")
+
 	// --- APLICA O MIDDLEWARE DE AUTH ---
-	// Isso protege todas as rotas abaixo
-	r.Use(middleware.APIKeyMiddleware)
+	// Isso protege todas as rotas do subrouter abaixo
+	api := r.NewRoute().Subrouter()
+	api.Use(middleware.APIKeyMiddleware)
 
 	// Rotas (agora protegidas)
-	r.HandleFunc("/listas", handler.GetListas).Methods("GET")
-	r.HandleFunc("/listas", handler.CreateLista).Methods("POST")
-	r.HandleFunc("/listas/{id}", handler.GetListaByID).Methods("GET")
-	r.HandleFunc("/listas/{id}/finalizar", handler.FinalizarID).Methods("PUT")
-	r.HandleFunc("/listas/{id}/itens", handler.AddItem).Methods("POST")
-	r.HandleFunc("/listas/{id}/itens", handler.DelItem).Methods("DELETE")
-	r.HandleFunc("/itens/{item_id}/check", handler.CheckItem).Methods("PUT")
+	api.HandleFunc("/listas", handler.GetListas).Methods("GET")
+	api.HandleFunc("/listas", handler.CreateLista).Methods("POST")
+	// Antes de /listas/{id}, senão "atual" seria tratado como ID
+	api.HandleFunc("/listas/atual", handler.GetListaAtual).Methods("GET")
+	api.HandleFunc("/listas/{id}", handler.GetListaByID).Methods("GET")
+	api.HandleFunc("/listas/{id}/finalizar", handler.FinalizarID).Methods("PUT")
+	api.HandleFunc("/listas/{id}/cancelar", handler.CancelarID).Methods("PUT")
+	api.HandleFunc("/listas/{id}/reabrir", handler.ReabrirID).Methods("PUT")
+	api.HandleFunc("/listas/{id}/duplicar", handler.DuplicarID).Methods("POST")
+	api.HandleFunc("/listas/{id}/itens", handler.AddItem).Methods("POST")
+	api.HandleFunc("/listas/{id}/itens/{item_id}", handler.UpdateItem).Methods("PATCH")
+	api.HandleFunc("/listas/{id}/itens/{item_id}", handler.DelItem).Methods("DELETE")
+	api.HandleFunc("/itens/{item_id}/check", handler.CheckItem).Methods("PUT")
+
+	// Tempo real (Server-Sent Events)
+	api.HandleFunc("/listas/{id}/eventos", handler.EventosLista).Methods("GET")
+
+	// Compartilhamento
+	api.HandleFunc("/listas/{id}/membros", handler.GetMembros).Methods("GET")
+	api.HandleFunc("/listas/{id}/membros", handler.ConvidarMembro).Methods("POST")
+	api.HandleFunc("/listas/{id}/membros/aceitar", handler.AceitarConvite).Methods("POST")
+	api.HandleFunc("/listas/{id}/membros/{user_id}", handler.RemoverMembro).Methods("DELETE")
+	api.HandleFunc("/convites", handler.GetConvites).Methods("GET")
+	api.HandleFunc("/listas/{id}/compartilhar", handler.GetLinks).Methods("GET")
+	api.HandleFunc("/listas/{id}/compartilhar", handler.Compartilhar).Methods("POST")
+	api.HandleFunc("/listas/{id}/compartilhar/{link_id}", handler.RevogarLink).Methods("DELETE")
+
+	// Administração: eventos de preço na dead-letter e métricas (expvar), que expõem a linha de comando e a memória
+	adm := api.PathPrefix("/admin").Subrouter()
+	adm.Use(middleware.AdminKeyMiddleware)
+	adm.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+	adm.HandleFunc("/precos/dead-letters", admin.GetDeadLetters).Methods("GET")
+	adm.HandleFunc("/precos/dead-letters/{id}", admin.GetDeadLetter).Methods("GET")
+	adm.HandleFunc("/precos/dead-letters/{id}/reprocessar", admin.ReprocessarDeadLetter).Methods("POST")
+	adm.HandleFunc("/precos/dead-letters/{id}", admin.DescartarDeadLetter).Methods("DELETE")
 
 	return r
 }
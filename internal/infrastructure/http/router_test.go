@@ -0,0 +1,41 @@
+package http
+
+import (
+	"comparei-servico-listas/internal/infrastructure/http/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMetricasExigemChaveDeAdministracao(t *testing.T) {
+	t.Setenv("API_KEY", "api")
+	t.Setenv("ADMIN_API_KEY", "admin")
+	router := NewRouter(&ListaHandler{}, &AdminHandler{}, middleware.NewRateLimiter(10, time.Minute, nil))
+
+	casos := []struct {
+		nome     string
+		rota     string
+		adminKey string
+		status   int
+	}{
+		{"rota antiga", "/debug/vars", "", http.StatusNotFound},
+		{"sem chave de administração", "/admin/debug/vars", "", http.StatusForbidden},
+		{"chave errada", "/admin/debug/vars", "outra", http.StatusForbidden},
+		{"com chave de administração", "/admin/debug/vars", "admin", http.StatusOK},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, c.rota, nil)
+			req.Header.Set("apiKey", "api")
+			if c.adminKey != "" {
+				req.Header.Set("adminKey", c.adminKey)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != c.status {
+				t.Fatalf("GET %s = %d, esperado %d", c.rota, w.Code, c.status)
+			}
+		})
+	}
+}
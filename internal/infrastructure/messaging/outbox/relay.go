@@ -0,0 +1,185 @@
+package outbox
+
+import (
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"expvar"
+	"log"
+	"time"
+)
+
+const (
+	tamanhoLote   = 100
+	esperaInicial = time.Second // espera após a primeira falha, dobrada a cada nova tentativa
+	esperaMaxima  = 5 * time.Minute
+	// reserva é por quanto tempo as mensagens lidas ficam fora da fila enquanto são publicadas. Se a réplica
+	// cair antes de registrar o resultado, elas voltam depois disso.
+	reserva         = time.Minute
+	retencaoEnviada = 7 * 24 * time.Hour
+	intervaloLimpa  = time.Hour
+)
+
+// Métricas expostas em /admin/debug/vars
+var (
+	metricaPendentes = expvar.NewInt("outbox_pendentes")
+	// metricaIdade é a idade, em segundos, da mensagem pendente mais antiga: cresce se o relay parar de publicar
+	metricaIdade    = expvar.NewFloat("outbox_idade_mais_antiga_segundos")
+	metricaEnviadas = expvar.NewInt("outbox_enviadas_total")
+	metricaFalhas   = expvar.NewInt("outbox_falhas_total")
+)
+
+// Relay publica as mensagens gravadas na outbox. A entrega é "ao menos uma vez": se o serviço cair entre
+// publicar e marcar como enviada, a mensagem sai de novo, e os consumidores descartam pelo ID do envelope.
+type Relay struct {
+	repo      interfaces.ListaRepository
+	publisher interfaces.Publisher
+	intervalo time.Duration
+}
+
+func NewRelay(repo interfaces.ListaRepository, publisher interfaces.Publisher, intervalo time.Duration) *Relay {
+	return &Relay{repo: repo, publisher: publisher, intervalo: intervalo}
+}
+
+// Run publica as pendências a cada intervalo até que ctx seja cancelado (desligamento do serviço)
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.intervalo)
+	defer ticker.Stop()
+
+	var ultimaLimpeza time.Time
+	for {
+		// Esvazia o que está pronto antes de esperar o próximo ciclo
+		for {
+			enviadas, err := r.processaLote(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Println("Erro ao processar a outbox:", err)
+				}
+				break
+			}
+			if enviadas < tamanhoLote {
+				break
+			}
+		}
+		r.atualizaMetricas(ctx)
+
+		if time.Since(ultimaLimpeza) >= intervaloLimpa {
+			r.limpa(ctx)
+			ultimaLimpeza = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Relay da outbox encerrado.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processaLote publica um lote de pendências em três passos, para que nenhuma transação espere pelo Redis
+// (no SQLite e no repositório em memória, uma transação aberta bloqueia todas as escritas do serviço):
+//  1. numa transação curta, trava o lote (outras réplicas pulam essas linhas) e o reserva;
+//  2. publica as mensagens, fora da transação;
+//  3. numa segunda transação curta, registra o resultado de cada uma.
+//
+// Retorna quantas mensagens foram lidas.
+func (r *Relay) processaLote(ctx context.Context) (int, error) {
+	var pendentes []listas.MensagemOutbox
+	err := r.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		agora := time.Now()
+		var err error
+		pendentes, err = repo.GetOutboxPendentes(ctx, agora, tamanhoLote)
+		if err != nil {
+			return err
+		}
+		for _, msg := range pendentes {
+			if err := repo.ReservaOutbox(ctx, msg.ID, agora.Add(reserva)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || len(pendentes) == 0 {
+		return 0, err
+	}
+
+	falhas := make([]error, len(pendentes))
+	for i, msg := range pendentes {
+		falhas[i] = r.publica(ctx, msg)
+		if falhas[i] != nil {
+			metricaFalhas.Add(1)
+			log.Printf("Erro ao publicar evento %s (tentativa %d): %v", msg.EventoID, msg.Tentativas+1, falhas[i])
+			continue
+		}
+		metricaEnviadas.Add(1)
+	}
+
+	// Se este passo falhar, as mensagens voltam quando a reserva vencer e as já publicadas saem de novo
+	err = r.repo.WithTx(ctx, func(repo interfaces.ListaRepository) error {
+		for i, msg := range pendentes {
+			if falhas[i] != nil {
+				if err := repo.MarcaOutboxFalha(ctx, msg.ID, falhas[i].Error(), time.Now().Add(espera(msg.Tentativas))); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := repo.MarcaOutboxEnviada(ctx, msg.ID, time.Now()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(pendentes), nil
+}
+
+func (r *Relay) publica(ctx context.Context, msg listas.MensagemOutbox) error {
+	envelope, err := msg.Envelope()
+	if err != nil {
+		return err
+	}
+	return r.publisher.Publica(ctx, envelope)
+}
+
+func (r *Relay) atualizaMetricas(ctx context.Context) {
+	backlog, err := r.repo.GetBacklogOutbox(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Println("Erro ao consultar o backlog da outbox:", err)
+		}
+		return
+	}
+
+	metricaPendentes.Set(int64(backlog.Pendentes))
+	if backlog.MaisAntiga == nil {
+		metricaIdade.Set(0)
+		return
+	}
+	metricaIdade.Set(time.Since(*backlog.MaisAntiga).Seconds())
+}
+
+// limpa apaga as mensagens já enviadas há mais tempo que a retenção, para a tabela não crescer sem limite
+func (r *Relay) limpa(ctx context.Context) {
+	removidas, err := r.repo.RemoveOutboxEnviadas(ctx, time.Now().Add(-retencaoEnviada))
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Println("Erro ao limpar a outbox:", err)
+		}
+		return
+	}
+	if removidas > 0 {
+		log.Printf("Outbox: %d mensagens enviadas removidas", removidas)
+	}
+}
+
+// espera calcula o atraso até a próxima tentativa: esperaInicial dobrando a cada falha, até esperaMaxima
+func espera(tentativas int) time.Duration {
+	d := esperaInicial
+	for i := 0; i < tentativas && d < esperaMaxima; i++ {
+		d *= 2
+	}
+	return min(d, esperaMaxima)
+}
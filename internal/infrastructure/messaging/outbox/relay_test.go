@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"comparei-servico-listas/internal/infrastructure/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// publisherFunc adapta uma função a interfaces.Publisher
+type publisherFunc func(ctx context.Context, envelope *listas.Envelope) error
+
+func (f publisherFunc) Publica(ctx context.Context, envelope *listas.Envelope) error {
+	return f(ctx, envelope)
+}
+
+func TestProcessaLotePublicaForaDaTransacao(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	for _, acao := range []string{"OK", "FALHA"} {
+		msg, err := listas.NovaMensagemOutbox(listas.LogEvento{UserID: "user-1", Acao: acao})
+		if err != nil {
+			t.Fatalf("NovaMensagemOutbox: %v", err)
+		}
+		if err := repo.InsertOutbox(ctx, msg); err != nil {
+			t.Fatalf("InsertOutbox: %v", err)
+		}
+	}
+
+	publisher := publisherFunc(func(ctx context.Context, envelope *listas.Envelope) error {
+		// Com a transação aberta, o repositório em memória estaria travado e esta escrita não terminaria
+		escrita := make(chan error, 1)
+		go func() {
+			_, err := repo.Create(ctx, &listas.Lista{UserID: "user-1", Nome: "Mercado", Status: listas.StatusAberta})
+			escrita <- err
+		}()
+		select {
+		case err := <-escrita:
+			if err != nil {
+				return err
+			}
+		case <-time.After(time.Second):
+			t.Fatal("publicação esperou por uma transação aberta")
+		}
+
+		var evento listas.LogEvento
+		if err := json.Unmarshal(envelope.Dados, &evento); err != nil {
+			return err
+		}
+		if evento.Acao == "FALHA" {
+			return errors.New("redis fora do ar")
+		}
+		return nil
+	})
+
+	relay := NewRelay(repo, publisher, time.Second)
+	lidas, err := relay.processaLote(ctx)
+	if err != nil {
+		t.Fatalf("processaLote: %v", err)
+	}
+	if lidas != 2 {
+		t.Fatalf("lidas = %d, esperado 2", lidas)
+	}
+
+	// A enviada sai da fila; a que falhou volta depois da espera, com a tentativa contada
+	pendentes, err := repo.GetOutboxPendentes(ctx, time.Now().Add(esperaMaxima), 10)
+	if err != nil {
+		t.Fatalf("GetOutboxPendentes: %v", err)
+	}
+	if len(pendentes) != 1 || pendentes[0].Tentativas != 1 {
+		t.Fatalf("pendentes = %+v, esperado só a que falhou, com 1 tentativa", pendentes)
+	}
+}
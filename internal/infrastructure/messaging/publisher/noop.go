@@ -0,0 +1,11 @@
+package publisher
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+)
+
+// Noop descarta os eventos: para testes e para rodar o serviço sem os consumidores
+type Noop struct{}
+
+func (Noop) Publica(context.Context, *listas.Envelope) error { return nil }
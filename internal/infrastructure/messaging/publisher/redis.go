@@ -0,0 +1,42 @@
+package publisher
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrSemAssinantes indica que ninguém estava inscrito no tópico quando o evento foi publicado
+var ErrSemAssinantes = errors.New("nenhum assinante no tópico")
+
+// Redis publica os eventos no Pub/Sub do Redis de mensageria, no tópico de cada tipo
+type Redis struct {
+	rdb *redis.Client
+}
+
+func NewRedis(rdb *redis.Client) *Redis {
+	return &Redis{rdb: rdb}
+}
+
+// Publica falha com ErrSemAssinantes se o PUBLISH não chegou a nenhum assinante: o Pub/Sub descartaria o evento,
+// e a falha o mantém na outbox para uma nova tentativa quando o consumidor voltar
+func (p *Redis) Publica(ctx context.Context, envelope *listas.Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	topico := Topico(envelope.Tipo)
+	assinantes, err := p.rdb.Publish(ctx, topico, payload).Result()
+	if err != nil {
+		return err
+	}
+	if assinantes == 0 {
+		return fmt.Errorf("%w %s", ErrSemAssinantes, topico)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package publisher
+
+import (
+	"bufio"
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// servidorPublish responde a todo comando com o número de assinantes informado, como faria o PUBLISH
+func servidorPublish(t *testing.T, assinantes int) *redis.Client {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					// Cada comando chega como um array RESP: "*N" seguido de N pares "$tamanho" e valor
+					linha, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					var n int
+					fmt.Sscanf(strings.TrimSpace(linha), "*%d", &n)
+					for i := 0; i < 2*n; i++ {
+						if _, err := r.ReadString('\n'); err != nil {
+							return
+						}
+					}
+					fmt.Fprintf(conn, ":%d\r\n", assinantes)
+				}
+			}()
+		}
+	}()
+
+	rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String()})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func TestPublicaSemAssinantesFalha(t *testing.T) {
+	envelope := &listas.Envelope{ID: "1", Tipo: listas.TipoLogEvento}
+
+	err := NewRedis(servidorPublish(t, 0)).Publica(context.Background(), envelope)
+	if !errors.Is(err, ErrSemAssinantes) || !strings.Contains(err.Error(), TopicoLogs) {
+		t.Fatalf("Publica sem assinantes = %v, esperado ErrSemAssinantes no tópico %s", err, TopicoLogs)
+	}
+
+	if err := NewRedis(servidorPublish(t, 2)).Publica(context.Background(), envelope); err != nil {
+		t.Fatalf("Publica com assinantes: %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package publisher
+
+import "comparei-servico-listas/internal/domain/listas"
+
+// Tópicos (canais do Redis) consumidos pelos outros serviços
+const (
+	TopicoConfirmarPreco = "confirmar_preco" // Serviço Produtos
+	TopicoLogs           = "log_evento"      // Serviço Logs
+	TopicoListas         = "listas_ciclo_vida"
+)
+
+// Topico indica em qual canal cada tipo de evento é publicado
+func Topico(tipo string) string {
+	switch tipo {
+	case listas.TipoItemComprado:
+		return TopicoConfirmarPreco
+	case listas.TipoLogEvento:
+		return TopicoLogs
+	}
+	return TopicoListas
+}
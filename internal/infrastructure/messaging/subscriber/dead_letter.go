@@ -0,0 +1,167 @@
+package subscriber
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Limite aproximado de entradas no stream de dead-letter; as mais antigas são descartadas
+const tamanhoMaximoDeadLetter = 10000
+
+// Métrica exposta em /admin/debug/vars
+var metricaDeadLetters = expvar.NewInt("precos_dead_letters_total")
+
+// idStream é o formato dos IDs de entradas de streams ("1700000000000-0")
+var idStream = regexp.MustCompile(`^\d+-\d+$`)
+
+// enviaDeadLetter guarda o evento no stream de dead-letter e o confirma no stream de origem, juntos (MULTI).
+// Se falhar, o evento continua pendente e volta a ser assumido na próxima varredura dos pendentes.
+func enviaDeadLetter(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos, msg redis.XMessage, payload string, causa error, tentativas int) {
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: cfg.StreamDeadLetter,
+			MaxLen: tamanhoMaximoDeadLetter,
+			Approx: true,
+			Values: map[string]interface{}{
+				CampoPayload: payload,
+				"erro":       causa.Error(),
+				"origem":     cfg.Stream,
+				"id_origem":  msg.ID,
+				"tentativas": tentativas,
+				"falhou_em":  time.Now().UTC().Format(time.RFC3339),
+			},
+		})
+		pipe.XAck(ctx, cfg.Stream, cfg.Grupo, msg.ID)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Erro ao enviar evento de preço %s para a dead-letter (fica pendente): %v", msg.ID, err)
+		return
+	}
+
+	metricaDeadLetters.Add(1)
+	log.Printf("Evento de preço %s enviado para %s: %v", msg.ID, cfg.StreamDeadLetter, causa)
+}
+
+// DeadLetters administra o stream de dead-letter dos eventos de preço
+type DeadLetters struct {
+	rdb *redis.Client
+	cfg ConfigPrecos
+}
+
+func NewDeadLetters(rdb *redis.Client, cfg ConfigPrecos) *DeadLetters {
+	return &DeadLetters{rdb: rdb, cfg: cfg}
+}
+
+func (d *DeadLetters) Lista(ctx context.Context, cursor string, limite int) ([]listas.DeadLetter, *string, error) {
+	inicio := "-"
+	if cursor != "" {
+		if !idStream.MatchString(cursor) {
+			return nil, nil, fmt.Errorf("%w: cursor", listas.ErrFiltroInvalido)
+		}
+		inicio = "(" + cursor // exclusivo
+	}
+
+	// Um a mais que o limite indica se existe próxima página
+	msgs, err := d.rdb.XRangeN(ctx, d.cfg.StreamDeadLetter, inicio, "+", int64(limite)+1).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var proximo *string
+	if len(msgs) > limite {
+		msgs = msgs[:limite]
+		proximo = &msgs[limite-1].ID
+	}
+
+	deadLetters := make([]listas.DeadLetter, 0, len(msgs))
+	for _, msg := range msgs {
+		deadLetters = append(deadLetters, deadLetterDe(msg))
+	}
+	return deadLetters, proximo, nil
+}
+
+func (d *DeadLetters) Busca(ctx context.Context, id string) (*listas.DeadLetter, error) {
+	if !idStream.MatchString(id) {
+		return nil, listas.ErrDeadLetterNaoEncontrado
+	}
+
+	msgs, err := d.rdb.XRangeN(ctx, d.cfg.StreamDeadLetter, id, id, 1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, listas.ErrDeadLetterNaoEncontrado
+	}
+	deadLetter := deadLetterDe(msgs[0])
+	return &deadLetter, nil
+}
+
+// Reprocessa adiciona o payload original de volta ao stream de origem e o remove da dead-letter, juntos (MULTI).
+// Se o preço já tiver sido alterado depois do evento (modified_at), ele é confirmado sem mudar nada.
+func (d *DeadLetters) Reprocessa(ctx context.Context, id string) (string, error) {
+	deadLetter, err := d.Busca(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	origem := deadLetter.Origem
+	if origem == "" {
+		origem = d.cfg.Stream
+	}
+
+	var novoID *redis.StringCmd
+	_, err = d.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		novoID = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: origem,
+			Values: map[string]interface{}{CampoPayload: deadLetter.Payload},
+		})
+		pipe.XDel(ctx, d.cfg.StreamDeadLetter, id)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return novoID.Val(), nil
+}
+
+func (d *DeadLetters) Descarta(ctx context.Context, id string) error {
+	if !idStream.MatchString(id) {
+		return listas.ErrDeadLetterNaoEncontrado
+	}
+
+	removidos, err := d.rdb.XDel(ctx, d.cfg.StreamDeadLetter, id).Result()
+	if err != nil {
+		return err
+	}
+	if removidos == 0 {
+		return listas.ErrDeadLetterNaoEncontrado
+	}
+	return nil
+}
+
+func deadLetterDe(msg redis.XMessage) listas.DeadLetter {
+	texto := func(campo string) string {
+		v, _ := msg.Values[campo].(string)
+		return v
+	}
+
+	deadLetter := listas.DeadLetter{
+		ID:       msg.ID,
+		Payload:  texto(CampoPayload),
+		Erro:     texto("erro"),
+		Origem:   texto("origem"),
+		IDOrigem: texto("id_origem"),
+	}
+	deadLetter.Tentativas, _ = strconv.Atoi(texto("tentativas"))
+	deadLetter.FalhouEm, _ = time.Parse(time.RFC3339, texto("falhou_em"))
+	return deadLetter
+}
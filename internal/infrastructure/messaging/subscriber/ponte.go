@@ -0,0 +1,61 @@
+package subscriber
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// janelaPonte é por quanto tempo a ponte lembra de um evento já repassado. Todas as réplicas recebem cada
+// PUBLISH: só a primeira a registrar o evento o adiciona ao stream.
+const janelaPonte = 10 * time.Minute
+
+// ponteCanalLegado repassa ao stream os eventos que o Promer ainda publica (PUBLISH) no canal de Pub/Sub.
+// É temporária: serve para a troca de PUBLISH por XADD no Promer não perder eventos, seja qual for a ordem
+// dos deploys, e pode ser desligada (PRECOS_CANAL_LEGADO vazio) depois que o Promer só usar o stream.
+// Como todo Pub/Sub, só repassa o que chegar com alguma réplica no ar.
+func ponteCanalLegado(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos) {
+	pubsub := rdb.Subscribe(ctx, cfg.CanalLegado)
+	defer pubsub.Close()
+
+	log.Printf("Repassando os eventos de preço do canal %s para o stream %s", cfg.CanalLegado, cfg.Stream)
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			repassa(ctx, rdb, cfg, msg.Payload)
+		}
+	}
+}
+
+func repassa(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos, payload string) {
+	soma := sha256.Sum256([]byte(payload))
+	chave := "precos:ponte:" + cfg.Stream + ":" + hex.EncodeToString(soma[:])
+
+	novo, err := rdb.SetNX(ctx, chave, 1, janelaPonte).Result()
+	if err != nil {
+		// Na dúvida, repassa: aplicar o mesmo preço duas vezes não muda o resultado, e perder o evento muda
+		log.Println("Erro ao registrar evento de preço da ponte, repassando mesmo assim:", err)
+		novo = true
+	}
+	if !novo {
+		return
+	}
+
+	err = rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: cfg.Stream,
+		Values: map[string]interface{}{CampoPayload: payload},
+	}).Err()
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Erro ao repassar evento de preço do canal %s, evento perdido: %v (payload: %s)", cfg.CanalLegado, err, payload)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -35,28 +36,200 @@ type PriceUpdateEvent struct {
 	MercadoProduto MercadoProdutos `json:"mercado_produto"`
 }
 
-func SubPriceUpdates() {
+// ConfigPrecos identifica o stream de eventos de preço e o grupo de consumidores deste serviço
+type ConfigPrecos struct {
+	Stream     string // stream em que o Promer adiciona (XADD) os eventos
+	Grupo      string // as réplicas do mesmo grupo dividem os eventos entre si
+	Consumidor string // nome desta réplica dentro do grupo
+
+	StreamDeadLetter string // para onde vão os eventos que falharam em todas as tentativas
+
+	// Um evento que falhou fica pendente e é entregue de novo (XCLAIM), por esta ou outra réplica, depois de
+	// EsperaReentrega, espera que dobra a cada entrega (até uma hora); se a entrega de número TentativasMaximas
+	// também falhar, vai para a dead-letter
+	TentativasMaximas int
+	EsperaReentrega   time.Duration
+
+	// CanalLegado é o canal de Pub/Sub em que versões antigas do Promer publicam os eventos. Enquanto
+	// preenchido, a ponte repassa esses eventos para Stream; vazio, só o stream é lido.
+	CanalLegado string
+}
+
+const (
+	// CampoPayload é o campo da entrada do stream que traz o JSON do PriceUpdateEvent
+	CampoPayload = "payload"
+
+	tamanhoLote   = 10
+	esperaLeitura = 5 * time.Second
+	// De quanto em quanto tempo, no máximo, a réplica procura entradas pendentes há mais de EsperaReentrega
+	intervaloReclamar = 30 * time.Second
+	// Limite da espera entre entregas, que dobra a cada falha
+	esperaReentregaMaxima = time.Hour
+)
+
+// SubPriceUpdates consome os eventos de preço até que ctx seja cancelado (desligamento do serviço).
+// Cada evento só é confirmado (XACK) depois de aplicado nas listas: o que chegar com o serviço fora do ar
+// fica no stream, e o que falhar fica pendente até ser assumido de novo. A entrega é "ao menos uma vez".
+// Nenhuma falha é tentada de novo na hora: a réplica segue para os próximos eventos enquanto o que falhou espera.
+func SubPriceUpdates(ctx context.Context, cfg ConfigPrecos) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr: os.Getenv("REDIS_MESSAGING_HOST") + ":" + os.Getenv("REDIS_MESSAGING_PORT"),
 	})
+	defer rdb.Close()
+
+	// Na primeira execução cria o grupo (e o stream, se o Promer ainda não publicou nada).
+	// "0" faz o grupo começar do início do stream, para não perder o que foi publicado antes dele existir.
+	err := rdb.XGroupCreateMkStream(ctx, cfg.Stream, cfg.Grupo, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		log.Println("Erro ao criar o grupo de consumidores de preços:", err)
+		return
+	}
 
-	ctx := context.Background()
-	pubsub := rdb.Subscribe(ctx, "update_product") // Tópico definido no Promer
-	defer pubsub.Close()
+	if cfg.CanalLegado != "" {
+		ponte := make(chan struct{})
+		go func() {
+			defer close(ponte)
+			ponteCanalLegado(ctx, rdb, cfg)
+		}()
+		// A ponte usa rdb: espera ela terminar antes do Close
+		defer func() { <-ponte }()
+	}
+
+	var ultimaReclamacao time.Time
+	for ctx.Err() == nil {
+		if time.Since(ultimaReclamacao) >= min(intervaloReclamar, cfg.EsperaReentrega) {
+			reclamaPendentes(ctx, rdb, cfg)
+			ultimaReclamacao = time.Now()
+		}
+
+		if err := leNovos(ctx, rdb, cfg); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Println("Erro ao ler eventos de preço:", err)
+			aguarda(ctx, time.Second)
+		}
+	}
+	log.Println("Subscriber de preços encerrado.")
+}
 
-	ch := pubsub.Channel()
+// leNovos espera até esperaLeitura por eventos que ainda não foram entregues a nenhuma réplica do grupo e os processa
+func leNovos(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos) error {
+	streams, err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    cfg.Grupo,
+		Consumer: cfg.Consumidor,
+		Streams:  []string{cfg.Stream, ">"},
+		Count:    tamanhoLote,
+		Block:    esperaLeitura,
+	}).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-	for msg := range ch {
-		var event PriceUpdateEvent
-		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
-			log.Println("Erro ao decodificar evento de preço:", err)
-			continue
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			processaPreco(ctx, rdb, cfg, msg, 1)
 		}
+	}
+	return nil
+}
 
-		log.Printf("Atualizando preço do produto %d nas listas...", event.MercadoProduto.ProdutoID)
-		err := listaService.UpdatePricesFromEvent(event.MercadoProduto.ProdutoID, event.MercadoProduto.MercadoID, float64(event.MercadoProduto.PrecoUnitario))
+// reclamaPendentes assume e processa, em qualquer consumidor do grupo, as entradas pendentes há mais tempo que a
+// espera da sua contagem de entregas (esperaEntrega): numa queda longa do banco, as novas tentativas se espaçam
+// em vez de esgotarem todas em poucos minutos
+func reclamaPendentes(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos) {
+	inicio := "-"
+	for {
+		pendentes, err := xPendentes(ctx, rdb, cfg, inicio)
 		if err != nil {
-			log.Println("Erro ao atualizar preços nas listas:", err)
+			if ctx.Err() == nil {
+				log.Println("Erro ao consultar eventos de preço pendentes:", err)
+			}
+			return
+		}
+
+		for _, p := range pendentes {
+			espera := esperaEntrega(cfg, p.RetryCount)
+			if p.Idle < espera {
+				continue
+			}
+			msg, apagada, err := xClaim(ctx, rdb, cfg, p.ID, espera)
+			if err != nil {
+				// A entrada continua pendente e volta na próxima varredura
+				if ctx.Err() == nil {
+					log.Println("Erro ao assumir evento de preço pendente:", err)
+				}
+				return
+			}
+			if apagada {
+				// No Redis 6.2 ela continuaria pendente para sempre
+				confirma(ctx, rdb, cfg, p.ID)
+				continue
+			}
+			if msg == nil {
+				continue // já confirmada ou assumida por outra réplica
+			}
+			// O XCLAIM conta mais uma entrega
+			processaPreco(ctx, rdb, cfg, *msg, p.RetryCount+1)
+		}
+
+		if len(pendentes) < tamanhoLote {
+			return
 		}
+		inicio = "(" + pendentes[len(pendentes)-1].ID
+	}
+}
+
+// processaPreco aplica o evento recebido pela entrega-ésima vez (1 na primeira leitura; o XCLAIM soma uma a cada
+// vez que assume a entrada)
+func processaPreco(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos, msg redis.XMessage, entrega int64) {
+	payload, _ := msg.Values[CampoPayload].(string)
+
+	var event PriceUpdateEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		// Tentar de novo não muda o resultado: vai direto para a dead-letter
+		log.Printf("Erro ao decodificar evento de preço %s: %v", msg.ID, err)
+		enviaDeadLetter(ctx, rdb, cfg, msg, payload, err, int(entrega))
+		return
+	}
+
+	log.Printf("Atualizando preço do produto %d nas listas...", event.MercadoProduto.ProdutoID)
+	listaIDs, err := listaService.UpdatePricesFromEvent(ctx, event.MercadoProduto.ProdutoID, event.MercadoProduto.MercadoID, float64(event.MercadoProduto.PrecoUnitario), event.MercadoProduto.ModifiedAt)
+	if err == nil {
+		log.Printf("Totais recalculados em %d listas: %v", len(listaIDs), listaIDs)
+		confirma(ctx, rdb, cfg, msg.ID)
+		return
+	}
+	// No desligamento o evento fica pendente, para outra réplica (ou esta, ao voltar) assumir
+	if ctx.Err() != nil {
+		return
+	}
+
+	if entrega >= int64(cfg.TentativasMaximas) {
+		log.Printf("Erro ao atualizar preços nas listas (evento %s, tentativa %d de %d): %v", msg.ID, entrega, cfg.TentativasMaximas, err)
+		enviaDeadLetter(ctx, rdb, cfg, msg, payload, err, int(entrega))
+		return
+	}
+	// Sem XACK: o evento fica pendente e é assumido de novo depois da espera desta entrega
+	log.Printf("Erro ao atualizar preços nas listas (evento %s, tentativa %d de %d, nova tentativa em %s): %v",
+		msg.ID, entrega, cfg.TentativasMaximas, esperaEntrega(cfg, entrega), err)
+}
+
+func confirma(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos, id string) {
+	if err := rdb.XAck(ctx, cfg.Stream, cfg.Grupo, id).Err(); err != nil {
+		log.Printf("Erro ao confirmar evento de preço %s: %v", id, err)
+	}
+}
+
+// aguarda dorme por d, ou menos se ctx for cancelado
+func aguarda(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
 	}
 }
@@ -0,0 +1,326 @@
+package subscriber
+
+import (
+	"comparei-servico-listas/internal/app"
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"comparei-servico-listas/internal/infrastructure/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var errBancoFora = errors.New("banco fora do ar")
+
+// repoInstavel simula uma queda do banco: enquanto fora estiver ligado, toda transação falha
+type repoInstavel struct {
+	*repository.MemoryRepository
+	fora atomic.Bool
+}
+
+func (r *repoInstavel) WithTx(ctx context.Context, fn func(repo interfaces.ListaRepository) error) error {
+	if r.fora.Load() {
+		return errBancoFora
+	}
+	return r.MemoryRepository.WithTx(ctx, fn)
+}
+
+type ambiente struct {
+	fake    *redisFake
+	rdb     *redis.Client
+	cfg     ConfigPrecos
+	repo    *repoInstavel
+	listaID int64
+}
+
+// novoAmbiente cria o grupo de consumidores no Redis fake e uma lista aberta com o produto 1 do mercado 7 a 10,00
+func novoAmbiente(t *testing.T) *ambiente {
+	t.Helper()
+	ctx := context.Background()
+	fake, rdb := novoRedisFake(t)
+	cfg := ConfigPrecos{
+		Stream:            "update_product",
+		Grupo:             "servico-listas",
+		Consumidor:        "replica-1",
+		StreamDeadLetter:  "update_product.dlq",
+		TentativasMaximas: 3,
+		EsperaReentrega:   time.Minute,
+	}
+	if err := rdb.XGroupCreateMkStream(ctx, cfg.Stream, cfg.Grupo, "0").Err(); err != nil {
+		t.Fatalf("XGroupCreateMkStream: %v", err)
+	}
+
+	repo := &repoInstavel{MemoryRepository: repository.NewMemoryRepository()}
+	service := app.NewListaService(repo, listas.PoliticaListasAbertas{}, nil, nil)
+	SetListaService(service)
+	t.Cleanup(func() { SetListaService(nil) })
+
+	listaID, err := service.CreateLista(ctx, &listas.Lista{UserID: "user-1", Nome: "Mercado"}, "")
+	if err != nil {
+		t.Fatalf("CreateLista: %v", err)
+	}
+	mercado := int64(7)
+	if err := service.AddItem(ctx, "user-1", &listas.ItemLista{ListaID: listaID, ProdutoID: 1, MercadoID: &mercado, PrecoUnitario: 10, Quantidade: 1}); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	return &ambiente{fake: fake, rdb: rdb, cfg: cfg, repo: repo, listaID: listaID}
+}
+
+// publica adiciona ao stream um evento de preço do produto 1 no mercado 7
+func (a *ambiente) publica(t *testing.T, preco float64, modificadoEm time.Time) {
+	t.Helper()
+	payload := fmt.Sprintf(`{"mercado_produto":{"id_produto":1,"id_mercado":7,"preco_unitario":%g,"modified_at":%q}}`,
+		preco, modificadoEm.Format(time.RFC3339Nano))
+	a.publicaPayload(t, payload)
+}
+
+func (a *ambiente) publicaPayload(t *testing.T, payload string) {
+	t.Helper()
+	if err := a.rdb.XAdd(context.Background(), &redis.XAddArgs{Stream: a.cfg.Stream, Values: map[string]interface{}{CampoPayload: payload}}).Err(); err != nil {
+		t.Fatalf("XAdd: %v", err)
+	}
+}
+
+func (a *ambiente) leNovos(t *testing.T) {
+	t.Helper()
+	if err := leNovos(context.Background(), a.rdb, a.cfg); err != nil {
+		t.Fatalf("leNovos: %v", err)
+	}
+}
+
+// pendentes retorna a contagem de entregas de cada entrada pendente no grupo
+func (a *ambiente) pendentes(t *testing.T) map[string]int64 {
+	t.Helper()
+	res, err := a.rdb.XPendingExt(context.Background(), &redis.XPendingExtArgs{Stream: a.cfg.Stream, Group: a.cfg.Grupo, Start: "-", End: "+", Count: 100}).Result()
+	if err != nil {
+		t.Fatalf("XPendingExt: %v", err)
+	}
+	entregas := map[string]int64{}
+	for _, p := range res {
+		entregas[p.ID] = p.RetryCount
+	}
+	return entregas
+}
+
+func (a *ambiente) deadLetters(t *testing.T) []listas.DeadLetter {
+	t.Helper()
+	deadLetters, _, err := NewDeadLetters(a.rdb, a.cfg).Lista(context.Background(), "", 100)
+	if err != nil {
+		t.Fatalf("Lista: %v", err)
+	}
+	return deadLetters
+}
+
+func (a *ambiente) precoNaLista(t *testing.T) float64 {
+	t.Helper()
+	lista, err := a.repo.FindByID(context.Background(), a.listaID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	return lista.Itens[0].PrecoUnitario
+}
+
+func TestFalhaVaiParaDeadLetterNaUltimaEntrega(t *testing.T) {
+	a := novoAmbiente(t)
+	a.repo.fora.Store(true)
+	a.publica(t, 12, time.Now())
+
+	a.leNovos(t)
+	if p := a.pendentes(t); len(p) != 1 || p["1-0"] != 1 {
+		t.Fatalf("pendentes = %v, esperado o evento 1-0 com 1 entrega", p)
+	}
+
+	// A espera dobra a cada entrega: 1m depois da primeira, 2m depois da segunda
+	entregas := []struct {
+		avanco   time.Duration
+		entregas int64
+	}{
+		{59 * time.Second, 1},
+		{time.Second, 2},
+		{time.Minute, 2},
+		{time.Minute, 3},
+	}
+	for _, e := range entregas {
+		a.fake.avanca(e.avanco)
+		reclamaPendentes(context.Background(), a.rdb, a.cfg)
+		if e.entregas == int64(a.cfg.TentativasMaximas) {
+			break
+		}
+		if p := a.pendentes(t); p["1-0"] != e.entregas {
+			t.Fatalf("depois de mais %s: entregas = %d, esperado %d", e.avanco, p["1-0"], e.entregas)
+		}
+		if len(a.deadLetters(t)) != 0 {
+			t.Fatalf("evento foi para a dead-letter na entrega %d de %d", e.entregas, a.cfg.TentativasMaximas)
+		}
+	}
+
+	// A terceira entrega também falhou: sai dos pendentes e vai para a dead-letter
+	if p := a.pendentes(t); len(p) != 0 {
+		t.Fatalf("pendentes = %v depois da última entrega, esperado nenhum", p)
+	}
+	deadLetters := a.deadLetters(t)
+	if len(deadLetters) != 1 {
+		t.Fatalf("dead-letters = %d, esperado 1", len(deadLetters))
+	}
+	if d := deadLetters[0]; d.IDOrigem != "1-0" || d.Tentativas != 3 || d.Origem != a.cfg.Stream || d.Erro != errBancoFora.Error() {
+		t.Fatalf("dead-letter = %+v", d)
+	}
+}
+
+func TestFalhaTransitoriaEAplicadaNaReentrega(t *testing.T) {
+	a := novoAmbiente(t)
+	a.repo.fora.Store(true)
+	a.publica(t, 12, time.Now())
+	a.leNovos(t)
+
+	a.repo.fora.Store(false)
+	a.fake.avanca(time.Minute)
+	reclamaPendentes(context.Background(), a.rdb, a.cfg)
+
+	if p := a.pendentes(t); len(p) != 0 {
+		t.Fatalf("pendentes = %v, esperado o evento confirmado", p)
+	}
+	if len(a.deadLetters(t)) != 0 {
+		t.Fatal("evento aplicado foi para a dead-letter")
+	}
+	if preco := a.precoNaLista(t); preco != 12 {
+		t.Fatalf("preço na lista = %v, esperado 12", preco)
+	}
+}
+
+func TestEntradaApagadaDoStreamSaiDosPendentes(t *testing.T) {
+	a := novoAmbiente(t)
+	a.repo.fora.Store(true)
+	a.publica(t, 12, time.Now())
+	a.leNovos(t)
+	if err := a.rdb.XDel(context.Background(), a.cfg.Stream, "1-0").Err(); err != nil {
+		t.Fatalf("XDel: %v", err)
+	}
+
+	a.fake.avanca(time.Minute)
+	reclamaPendentes(context.Background(), a.rdb, a.cfg)
+	if p := a.pendentes(t); len(p) != 0 {
+		t.Fatalf("pendentes = %v, esperado a entrada apagada confirmada", p)
+	}
+	if len(a.deadLetters(t)) != 0 {
+		t.Fatal("entrada apagada foi para a dead-letter")
+	}
+}
+
+func TestPayloadInvalidoVaiDiretoParaDeadLetter(t *testing.T) {
+	a := novoAmbiente(t)
+	a.publicaPayload(t, "não é json")
+	a.leNovos(t)
+
+	if p := a.pendentes(t); len(p) != 0 {
+		t.Fatalf("pendentes = %v, esperado o evento confirmado", p)
+	}
+	deadLetters := a.deadLetters(t)
+	if len(deadLetters) != 1 || deadLetters[0].Payload != "não é json" || deadLetters[0].Tentativas != 1 {
+		t.Fatalf("dead-letters = %+v, esperado o payload inválido na primeira entrega", deadLetters)
+	}
+	if preco := a.precoNaLista(t); preco != 10 {
+		t.Fatalf("preço na lista = %v, esperado 10", preco)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	a := novoAmbiente(t)
+	dl := NewDeadLetters(a.rdb, a.cfg)
+	a.repo.fora.Store(true)
+	a.cfg.TentativasMaximas = 1
+	a.publica(t, 12, time.Now())
+	a.publicaPayload(t, "lixo")
+	a.leNovos(t)
+
+	deadLetters, proximo, err := dl.Lista(ctx, "", 1)
+	if err != nil || len(deadLetters) != 1 || proximo == nil {
+		t.Fatalf("Lista(1) = %d, %v, %v; esperado 1 e um cursor", len(deadLetters), proximo, err)
+	}
+	valido := deadLetters[0]
+	resto, proximo, err := dl.Lista(ctx, *proximo, 1)
+	if err != nil || len(resto) != 1 || proximo != nil || resto[0].Payload != "lixo" {
+		t.Fatalf("segunda página = %+v, %v, %v; esperado só o payload inválido", resto, proximo, err)
+	}
+	invalido := resto[0]
+
+	// Reprocessar devolve o payload ao stream de origem e tira da dead-letter
+	a.repo.fora.Store(false)
+	if _, err := dl.Reprocessa(ctx, valido.ID); err != nil {
+		t.Fatalf("Reprocessa: %v", err)
+	}
+	if _, err := dl.Busca(ctx, valido.ID); !errors.Is(err, listas.ErrDeadLetterNaoEncontrado) {
+		t.Fatalf("Busca depois de reprocessar = %v, esperado %v", err, listas.ErrDeadLetterNaoEncontrado)
+	}
+	a.leNovos(t)
+	if preco := a.precoNaLista(t); preco != 12 {
+		t.Fatalf("preço na lista = %v depois de reprocessar, esperado 12", preco)
+	}
+
+	if err := dl.Descarta(ctx, invalido.ID); err != nil {
+		t.Fatalf("Descarta: %v", err)
+	}
+	if restantes := a.deadLetters(t); len(restantes) != 0 {
+		t.Fatalf("dead-letters = %+v, esperado nenhuma", restantes)
+	}
+
+	// IDs que não existem ou fora do formato dos streams
+	for _, id := range []string{invalido.ID, "abc"} {
+		if err := dl.Descarta(ctx, id); !errors.Is(err, listas.ErrDeadLetterNaoEncontrado) {
+			t.Fatalf("Descarta(%q) = %v, esperado %v", id, err, listas.ErrDeadLetterNaoEncontrado)
+		}
+		if _, err := dl.Reprocessa(ctx, id); !errors.Is(err, listas.ErrDeadLetterNaoEncontrado) {
+			t.Fatalf("Reprocessa(%q) = %v, esperado %v", id, err, listas.ErrDeadLetterNaoEncontrado)
+		}
+	}
+}
+
+func TestReprocessarEventoAntigoNaoDesfazPrecoNovo(t *testing.T) {
+	ctx := context.Background()
+	a := novoAmbiente(t)
+	a.cfg.TentativasMaximas = 1
+	alterado := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	a.repo.fora.Store(true)
+	a.publica(t, 11, alterado)
+	a.leNovos(t)
+
+	a.repo.fora.Store(false)
+	a.publica(t, 12, alterado.Add(time.Minute))
+	a.leNovos(t)
+
+	antigo := a.deadLetters(t)[0]
+	if _, err := NewDeadLetters(a.rdb, a.cfg).Reprocessa(ctx, antigo.ID); err != nil {
+		t.Fatalf("Reprocessa: %v", err)
+	}
+	a.leNovos(t)
+
+	if p := a.pendentes(t); len(p) != 0 {
+		t.Fatalf("pendentes = %v, esperado o evento antigo confirmado", p)
+	}
+	if preco := a.precoNaLista(t); preco != 12 {
+		t.Fatalf("preço na lista = %v, esperado 12 (o evento reprocessado é mais antigo)", preco)
+	}
+}
+
+func TestEsperaEntrega(t *testing.T) {
+	cfg := ConfigPrecos{EsperaReentrega: time.Minute}
+	esperas := map[int64]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		4:  8 * time.Minute,
+		7:  esperaReentregaMaxima,
+		40: esperaReentregaMaxima,
+	}
+	for entregas, esperada := range esperas {
+		if espera := esperaEntrega(cfg, entregas); espera != esperada {
+			t.Errorf("esperaEntrega(%d) = %s, esperado %s", entregas, espera, esperada)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package subscriber
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisFake é um servidor RESP em memória com os comandos de streams que o subscriber usa. O relógio só anda
+// com avanca, para os testes controlarem há quanto tempo cada entrada está pendente.
+type redisFake struct {
+	mu      sync.Mutex
+	agora   time.Time
+	streams map[string]*streamFake
+}
+
+type streamFake struct {
+	entradas []entradaFake // em ordem de ID
+	ultimoID int64
+	grupos   map[string]*grupoFake
+}
+
+type entradaFake struct {
+	id     string
+	campos []interface{}
+}
+
+type grupoFake struct {
+	ultimoEntregue string
+	pendentes      map[string]*pendenteFake
+}
+
+type pendenteFake struct {
+	consumidor string
+	entregueEm time.Time
+	entregas   int64
+}
+
+// respostas que não são bulk strings
+type (
+	statusFake string
+	erroFake   string
+)
+
+// novoRedisFake sobe o servidor e retorna um cliente conectado a ele
+func novoRedisFake(t *testing.T) (*redisFake, *redis.Client) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	fake := &redisFake{agora: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), streams: map[string]*streamFake{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go fake.atende(conn)
+		}
+	}()
+
+	rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String()})
+	t.Cleanup(func() { rdb.Close() })
+	return fake, rdb
+}
+
+func (f *redisFake) avanca(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.agora = f.agora.Add(d)
+}
+
+func (f *redisFake) atende(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	var fila [][]string // comandos entre MULTI e EXEC
+	emMulti := false
+	for {
+		cmd, err := leComando(r)
+		if err != nil {
+			return
+		}
+
+		switch nome := strings.ToUpper(cmd[0]); {
+		case nome == "MULTI":
+			emMulti, fila = true, nil
+			escreve(w, statusFake("OK"))
+		case nome == "EXEC":
+			f.mu.Lock()
+			respostas := make([]interface{}, len(fila))
+			for i, c := range fila {
+				respostas[i] = f.executa(c)
+			}
+			f.mu.Unlock()
+			emMulti = false
+			escreve(w, respostas)
+		case emMulti:
+			fila = append(fila, cmd)
+			escreve(w, statusFake("QUEUED"))
+		default:
+			f.mu.Lock()
+			resposta := f.executa(cmd)
+			f.mu.Unlock()
+			escreve(w, resposta)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (f *redisFake) stream(chave string) *streamFake {
+	s, ok := f.streams[chave]
+	if !ok {
+		s = &streamFake{grupos: map[string]*grupoFake{}}
+		f.streams[chave] = s
+	}
+	return s
+}
+
+// executa roda um comando com f.mu travado
+func (f *redisFake) executa(cmd []string) interface{} {
+	args := cmd[1:]
+	switch strings.ToUpper(cmd[0]) {
+	case "PING":
+		return statusFake("PONG")
+
+	case "XGROUP": // XGROUP CREATE chave grupo id MKSTREAM
+		s := f.stream(args[1])
+		if _, ok := s.grupos[args[2]]; ok {
+			return erroFake("BUSYGROUP Consumer Group name already exists")
+		}
+		s.grupos[args[2]] = &grupoFake{ultimoEntregue: "0-0", pendentes: map[string]*pendenteFake{}}
+		return statusFake("OK")
+
+	case "XADD": // XADD chave [MAXLEN [~] n] * campo valor ...
+		s := f.stream(args[0])
+		i := 1
+		if strings.EqualFold(args[i], "MAXLEN") {
+			i += 2
+			if args[i-1] == "~" || args[i-1] == "=" {
+				i++
+			}
+		}
+		s.ultimoID++
+		entrada := entradaFake{id: fmt.Sprintf("%d-0", s.ultimoID)}
+		for _, v := range args[i+1:] {
+			entrada.campos = append(entrada.campos, v)
+		}
+		s.entradas = append(s.entradas, entrada)
+		return entrada.id
+
+	case "XDEL":
+		s := f.stream(args[0])
+		var removidas int64
+		for _, id := range args[1:] {
+			for i, e := range s.entradas {
+				if e.id == id {
+					s.entradas = append(s.entradas[:i], s.entradas[i+1:]...)
+					removidas++
+					break
+				}
+			}
+		}
+		return removidas
+
+	case "XRANGE": // XRANGE chave inicio fim [COUNT n]
+		s := f.stream(args[0])
+		limite := len(s.entradas)
+		if len(args) == 5 {
+			limite, _ = strconv.Atoi(args[4])
+		}
+		var res []interface{}
+		for _, e := range s.entradas {
+			if len(res) < limite && depoisDoInicio(e.id, args[1]) && antesDoFim(e.id, args[2]) {
+				res = append(res, entradaResp(e))
+			}
+		}
+		return res
+
+	case "XREADGROUP": // XREADGROUP GROUP grupo consumidor [COUNT n] [BLOCK ms] STREAMS chave >
+		g := f.stream(args[len(args)-2]).grupos[args[1]]
+		if g == nil {
+			return erroFake("NOGROUP No such consumer group")
+		}
+		limite := 1
+		for i := 3; i < len(args)-1; i++ {
+			if strings.EqualFold(args[i], "COUNT") {
+				limite, _ = strconv.Atoi(args[i+1])
+			}
+		}
+		var entregues []interface{}
+		for _, e := range f.stream(args[len(args)-2]).entradas {
+			if len(entregues) == limite || comparaID(e.id, g.ultimoEntregue) <= 0 {
+				continue
+			}
+			g.ultimoEntregue = e.id
+			g.pendentes[e.id] = &pendenteFake{consumidor: args[2], entregueEm: f.agora, entregas: 1}
+			entregues = append(entregues, entradaResp(e))
+		}
+		if len(entregues) == 0 {
+			return nil // o BLOCK venceria sem nada novo
+		}
+		return []interface{}{[]interface{}{args[len(args)-2], entregues}}
+
+	case "XACK":
+		g := f.stream(args[0]).grupos[args[1]]
+		var confirmadas int64
+		for _, id := range args[2:] {
+			if _, ok := g.pendentes[id]; ok {
+				delete(g.pendentes, id)
+				confirmadas++
+			}
+		}
+		return confirmadas
+
+	case "XPENDING": // XPENDING chave grupo [IDLE ms] inicio fim quantidade
+		g := f.stream(args[0]).grupos[args[1]]
+		var ocioso time.Duration
+		resto := args[2:]
+		if strings.EqualFold(resto[0], "IDLE") {
+			ms, _ := strconv.Atoi(resto[1])
+			ocioso = time.Duration(ms) * time.Millisecond
+			resto = resto[2:]
+		}
+		limite, _ := strconv.Atoi(resto[2])
+
+		ids := make([]string, 0, len(g.pendentes))
+		for id := range g.pendentes {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(a, b int) bool { return comparaID(ids[a], ids[b]) < 0 })
+
+		var res []interface{}
+		for _, id := range ids {
+			p := g.pendentes[id]
+			idle := f.agora.Sub(p.entregueEm)
+			if len(res) == limite || idle < ocioso || !depoisDoInicio(id, resto[0]) || !antesDoFim(id, resto[1]) {
+				continue
+			}
+			res = append(res, []interface{}{id, p.consumidor, idle.Milliseconds(), p.entregas})
+		}
+		return res
+
+	case "XCLAIM": // XCLAIM chave grupo consumidor min-idle id ...
+		s := f.stream(args[0])
+		g := s.grupos[args[1]]
+		ms, _ := strconv.Atoi(args[3])
+		minIdle := time.Duration(ms) * time.Millisecond
+		var res []interface{}
+		for _, id := range args[4:] {
+			p, ok := g.pendentes[id]
+			if !ok || f.agora.Sub(p.entregueEm) < minIdle {
+				continue
+			}
+			p.consumidor, p.entregueEm = args[2], f.agora
+			p.entregas++
+			// Como o Redis 6.2: a entrada apagada do stream é assumida, continua pendente e vem como nil
+			if e, existe := s.busca(id); existe {
+				res = append(res, entradaResp(e))
+			} else {
+				res = append(res, nil)
+			}
+		}
+		return res
+	}
+	return erroFake("ERR comando não suportado pelo fake: " + cmd[0])
+}
+
+func (s *streamFake) busca(id string) (entradaFake, bool) {
+	for _, e := range s.entradas {
+		if e.id == id {
+			return e, true
+		}
+	}
+	return entradaFake{}, false
+}
+
+func entradaResp(e entradaFake) interface{} {
+	return []interface{}{e.id, e.campos}
+}
+
+// comparaID compara IDs de streams ("ms-seq") como o Redis
+func comparaID(a, b string) int {
+	partes := func(id string) (int64, int64) {
+		ms, seq, _ := strings.Cut(id, "-")
+		x, _ := strconv.ParseInt(ms, 10, 64)
+		y, _ := strconv.ParseInt(seq, 10, 64)
+		return x, y
+	}
+	am, as := partes(a)
+	bm, bs := partes(b)
+	switch {
+	case am != bm:
+		return int(am - bm)
+	case as != bs:
+		return int(as - bs)
+	}
+	return 0
+}
+
+func depoisDoInicio(id, inicio string) bool {
+	switch {
+	case inicio == "-":
+		return true
+	case strings.HasPrefix(inicio, "("):
+		return comparaID(id, inicio[1:]) > 0
+	}
+	return comparaID(id, inicio) >= 0
+}
+
+func antesDoFim(id, fim string) bool {
+	return fim == "+" || comparaID(id, fim) <= 0
+}
+
+// leComando lê um array RESP de bulk strings
+func leComando(r *bufio.Reader) ([]string, error) {
+	linha, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(linha[1:]))
+	if err != nil {
+		return nil, err
+	}
+	cmd := make([]string, n)
+	for i := range cmd {
+		linha, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		tamanho, err := strconv.Atoi(strings.TrimSpace(linha[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, tamanho+2) // com o \r\n
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		cmd[i] = string(buf[:tamanho])
+	}
+	return cmd, nil
+}
+
+func escreve(w *bufio.Writer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		w.WriteString("*-1\r\n")
+	case statusFake:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case erroFake:
+		fmt.Fprintf(w, "-%s\r\n", v)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []interface{}:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			escreve(w, item)
+		}
+	}
+}
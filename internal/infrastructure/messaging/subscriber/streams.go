@@ -0,0 +1,60 @@
+package subscriber
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// esperaEntrega é quanto uma entrada entregue entregas vezes fica pendente antes de ser assumida de novo:
+// EsperaReentrega depois da primeira entrega, dobrando a cada nova entrega, até esperaReentregaMaxima
+func esperaEntrega(cfg ConfigPrecos, entregas int64) time.Duration {
+	d := cfg.EsperaReentrega
+	for i := int64(1); i < entregas && d < esperaReentregaMaxima; i++ {
+		d *= 2
+	}
+	return min(d, esperaReentregaMaxima)
+}
+
+// xPendentes lista até tamanhoLote entradas pendentes há mais de EsperaReentrega, em qualquer consumidor do grupo,
+// a partir de inicio ("-" na primeira página, "(" + último ID nas seguintes)
+func xPendentes(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos, inicio string) ([]redis.XPendingExt, error) {
+	return rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: cfg.Stream,
+		Group:  cfg.Grupo,
+		Idle:   cfg.EsperaReentrega,
+		Start:  inicio,
+		End:    "+",
+		Count:  tamanhoLote,
+	}).Result()
+}
+
+// xClaim assume a entrada se ela ainda estiver pendente há pelo menos minIdle: duas réplicas que a encontrem
+// na mesma varredura não a processam juntas, porque o XCLAIM da primeira zera o tempo pendente.
+// Retorna nil se a entrada já foi confirmada ou assumida por outra réplica, e apagada = true se ela foi apagada
+// do stream, caso em que o Redis 6.2 a assume mas devolve nil (o Redis 7 a tira dos pendentes e não devolve nada).
+// O comando é enviado direto porque o XClaim do go-redis v8 não entende esse nil.
+func xClaim(ctx context.Context, rdb *redis.Client, cfg ConfigPrecos, id string, minIdle time.Duration) (msg *redis.XMessage, apagada bool, err error) {
+	res, err := rdb.Do(ctx, "XCLAIM", cfg.Stream, cfg.Grupo, cfg.Consumidor, int64(minIdle/time.Millisecond), id).Slice()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, e := range res {
+		campos, ok := e.([]interface{})
+		if !ok || len(campos) != 2 {
+			return nil, true, nil
+		}
+		msgID, _ := campos[0].(string)
+		valores, _ := campos[1].([]interface{})
+
+		msg := redis.XMessage{ID: msgID, Values: make(map[string]interface{}, len(valores)/2)}
+		for i := 0; i+1 < len(valores); i += 2 {
+			if chave, ok := valores[i].(string); ok {
+				msg.Values[chave] = valores[i+1]
+			}
+		}
+		return &msg, false, nil
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,299 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var arquivoRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Versao    int
+	Descricao string
+	Up        string
+	Down      string
+}
+
+// Migrator aplica as migrações versionadas e registra as aplicadas em schema_migrations
+type Migrator struct {
+	db        *sql.DB
+	migracoes []Migration
+	lock      Lock
+}
+
+// New lê as migrações do diretório dir de fsys (ex.: migrations.MySQL, "mysql"); lock depende do banco
+func New(db *sql.DB, fsys fs.FS, dir string, lock Lock) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	porVersao := map[int]*Migration{}
+	for _, entry := range entries {
+		m := arquivoRegex.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+
+		versao, _ := strconv.Atoi(m[1])
+		conteudo, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := porVersao[versao]
+		if !ok {
+			mig = &Migration{Versao: versao, Descricao: m[2]}
+			porVersao[versao] = mig
+		}
+		if m[3] == "up" {
+			mig.Up = string(conteudo)
+		} else {
+			mig.Down = string(conteudo)
+		}
+	}
+
+	migrator := &Migrator{db: db, lock: lock}
+	for _, mig := range porVersao {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migração %04d_%s sem arquivo up ou down", mig.Versao, mig.Descricao)
+		}
+		migrator.migracoes = append(migrator.migracoes, *mig)
+	}
+	sort.Slice(migrator.migracoes, func(a, b int) bool {
+		return migrator.migracoes[a].Versao < migrator.migracoes[b].Versao
+	})
+
+	return migrator, nil
+}
+
+// Latest é a versão mais nova conhecida por este binário
+func (m *Migrator) Latest() int {
+	if len(m.migracoes) == 0 {
+		return 0
+	}
+	return m.migracoes[len(m.migracoes)-1].Versao
+}
+
+// Version retorna a versão atual do banco (0 se nenhuma migração foi aplicada)
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return 0, err
+	}
+	return currentVersion(ctx, conn)
+}
+
+// Check falha se o banco não estiver exatamente na versão mais nova conhecida pelo binário
+func (m *Migrator) Check(ctx context.Context) error {
+	versao, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case versao > m.Latest():
+		return fmt.Errorf("schema na versão %d, mas este binário só conhece até a %d", versao, m.Latest())
+	case versao < m.Latest():
+		return fmt.Errorf("schema na versão %d, esperado %d: execute \"migrate up\"", versao, m.Latest())
+	}
+	return nil
+}
+
+// Up aplica todas as migrações pendentes e retorna quantas foram aplicadas
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	aplicadas := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versao, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if versao > m.Latest() {
+			return fmt.Errorf("schema na versão %d, mas este binário só conhece até a %d", versao, m.Latest())
+		}
+
+		for _, mig := range m.migracoes {
+			if mig.Versao <= versao {
+				continue
+			}
+			log.Printf("Aplicando migração %04d_%s...", mig.Versao, mig.Descricao)
+			if err := execScript(ctx, conn, mig.Up); err != nil {
+				return fmt.Errorf("migração %04d_%s: %w", mig.Versao, mig.Descricao, err)
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES (?)", mig.Versao); err != nil {
+				return err
+			}
+			aplicadas++
+		}
+		return nil
+	})
+	return aplicadas, err
+}
+
+// Down desfaz as últimas n migrações aplicadas
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	desfeitas := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		for desfeitas < n {
+			versao, err := currentVersion(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if versao == 0 {
+				return nil
+			}
+
+			mig, ok := m.find(versao)
+			if !ok {
+				return fmt.Errorf("versão %d do banco não existe neste binário", versao)
+			}
+
+			log.Printf("Desfazendo migração %04d_%s...", mig.Versao, mig.Descricao)
+			if err := execScript(ctx, conn, mig.Down); err != nil {
+				return fmt.Errorf("migração %04d_%s: %w", mig.Versao, mig.Descricao, err)
+			}
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", mig.Versao); err != nil {
+				return err
+			}
+			desfeitas++
+		}
+		return nil
+	})
+	return desfeitas, err
+}
+
+// Force marca o banco como estando na versão informada, sem executar scripts.
+// Serve para corrigir uma migração que falhou no meio, num banco sem DDL transacional (MySQL).
+func (m *Migrator) Force(ctx context.Context, versao int) error {
+	if versao != 0 {
+		if _, ok := m.find(versao); !ok {
+			return fmt.Errorf("versão %d desconhecida", versao)
+		}
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+			return err
+		}
+		for _, mig := range m.migracoes {
+			if mig.Versao > versao {
+				break
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES (?)", mig.Versao); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) find(versao int) (Migration, bool) {
+	for _, mig := range m.migracoes {
+		if mig.Versao == versao {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock executa fn numa conexão dedicada que detém o lock, para que só uma instância migre por vez
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return m.lock(ctx, conn, func() error {
+		if err := ensureTable(ctx, conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// Lock executa fn em conn enquanto impede que outra instância migre o mesmo banco
+type Lock func(ctx context.Context, conn *sql.Conn, fn func() error) error
+
+// Nome do lock do MySQL (GET_LOCK) que impede duas instâncias de migrarem ao mesmo tempo
+const lockName = "comparei_servico_listas_migrations"
+
+// LockMySQL usa GET_LOCK, esperando até timeout pela instância que estiver migrando.
+// O MySQL faz commit implícito a cada DDL, então não há transação em volta dos scripts.
+func LockMySQL(timeout time.Duration) Lock {
+	return func(ctx context.Context, conn *sql.Conn, fn func() error) error {
+		var obtido sql.NullInt64
+		err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(timeout.Seconds())).Scan(&obtido)
+		if err != nil {
+			return err
+		}
+		if !obtido.Valid || obtido.Int64 != 1 {
+			return errors.New("não foi possível obter o lock de migração: outra instância está migrando")
+		}
+		defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+
+		return fn()
+	}
+}
+
+// LockSQLite abre uma transação BEGIN IMMEDIATE, que trava o banco para escrita: como o DDL do SQLite é
+// transacional, cada comando do migrador é aplicado por inteiro ou desfeito se algo falhar
+func LockSQLite() Lock {
+	return func(ctx context.Context, conn *sql.Conn, fn func() error) error {
+		if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			conn.ExecContext(context.Background(), "ROLLBACK")
+			return err
+		}
+		_, err := conn.ExecContext(ctx, "COMMIT")
+		return err
+	}
+}
+
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+func currentVersion(ctx context.Context, conn *sql.Conn) (int, error) {
+	var versao sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&versao); err != nil {
+		return 0, err
+	}
+	return int(versao.Int64), nil
+}
+
+// execScript executa um arquivo com vários comandos, separados por ";" no fim da linha
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, stmt := range strings.Split(script, ";\n") {
+		stmt = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+		if stmt == "" {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
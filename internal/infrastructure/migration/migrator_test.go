@@ -0,0 +1,168 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+// migracoesTeste são duas migrações que criam uma tabela cada
+var migracoesTeste = fstest.MapFS{
+	"sql/0001_a.up.sql":   {Data: []byte("CREATE TABLE a (id INT);\n")},
+	"sql/0001_a.down.sql": {Data: []byte("DROP TABLE a;\n")},
+	"sql/0002_b.up.sql":   {Data: []byte("CREATE TABLE b (id INT);\nINSERT INTO b VALUES (1);\n")},
+	"sql/0002_b.down.sql": {Data: []byte("DROP TABLE b;\n")},
+}
+
+func novoMigrator(t *testing.T, fsys fstest.MapFS) (*Migrator, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := New(db, fsys, "sql", LockSQLite())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return migrator, db
+}
+
+func versao(t *testing.T, migrator *Migrator) int {
+	t.Helper()
+	v, err := migrator.Version(context.Background())
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	return v
+}
+
+func existeTabela(t *testing.T, db *sql.DB, nome string) bool {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", nome).Scan(&n); err != nil {
+		t.Fatalf("sqlite_master: %v", err)
+	}
+	return n > 0
+}
+
+func TestUpDoBancoVazio(t *testing.T) {
+	ctx := context.Background()
+	migrator, db := novoMigrator(t, migracoesTeste)
+
+	aplicadas, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if aplicadas != 2 || versao(t, migrator) != 2 {
+		t.Fatalf("Up aplicou %d, versão %d; esperado 2 e 2", aplicadas, versao(t, migrator))
+	}
+	if !existeTabela(t, db, "a") || !existeTabela(t, db, "b") {
+		t.Fatal("tabelas das migrações não foram criadas")
+	}
+
+	// Sem pendências, não faz nada
+	if aplicadas, err := migrator.Up(ctx); err != nil || aplicadas != 0 {
+		t.Fatalf("segundo Up = %d, %v; esperado 0, nil", aplicadas, err)
+	}
+}
+
+func TestDown(t *testing.T) {
+	ctx := context.Background()
+	migrator, db := novoMigrator(t, migracoesTeste)
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	if desfeitas, err := migrator.Down(ctx, 1); err != nil || desfeitas != 1 {
+		t.Fatalf("Down(1) = %d, %v; esperado 1, nil", desfeitas, err)
+	}
+	if versao(t, migrator) != 1 || existeTabela(t, db, "b") || !existeTabela(t, db, "a") {
+		t.Fatalf("depois do Down(1): versão %d; esperado 1, só com a tabela a", versao(t, migrator))
+	}
+
+	// Pedir mais do que há para desfazer para na versão 0
+	if desfeitas, err := migrator.Down(ctx, 5); err != nil || desfeitas != 1 {
+		t.Fatalf("Down(5) = %d, %v; esperado 1, nil", desfeitas, err)
+	}
+	if versao(t, migrator) != 0 || existeTabela(t, db, "a") {
+		t.Fatalf("depois do Down(5): versão %d; esperado 0, sem tabelas", versao(t, migrator))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	migrator, db := novoMigrator(t, migracoesTeste)
+
+	if err := migrator.Check(ctx); err == nil || !strings.Contains(err.Error(), "migrate up") {
+		t.Fatalf("Check num banco vazio = %v, esperado pedir o migrate up", err)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("Check na versão mais nova: %v", err)
+	}
+
+	// Banco migrado por um binário mais novo
+	if _, err := db.Exec("INSERT INTO schema_migrations (version) VALUES (3)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := migrator.Check(ctx); err == nil || !strings.Contains(err.Error(), "só conhece até a 2") {
+		t.Fatalf("Check num banco mais novo = %v, esperado recusar", err)
+	}
+	if _, err := migrator.Up(ctx); err == nil {
+		t.Fatal("Up num banco mais novo não falhou")
+	}
+}
+
+func TestForce(t *testing.T) {
+	ctx := context.Background()
+	migrator, db := novoMigrator(t, migracoesTeste)
+
+	if err := migrator.Force(ctx, 1); err != nil {
+		t.Fatalf("Force(1): %v", err)
+	}
+	// Marca a versão sem executar o script
+	if versao(t, migrator) != 1 || existeTabela(t, db, "a") {
+		t.Fatalf("depois do Force(1): versão %d; esperado 1, sem a tabela a", versao(t, migrator))
+	}
+
+	if err := migrator.Force(ctx, 7); err == nil {
+		t.Fatal("Force de uma versão desconhecida não falhou")
+	}
+	if err := migrator.Force(ctx, 0); err != nil || versao(t, migrator) != 0 {
+		t.Fatalf("Force(0) = %v, versão %d; esperado nil e 0", err, versao(t, migrator))
+	}
+}
+
+func TestRollbackComLockSQLite(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"sql/0001_a.up.sql":   migracoesTeste["sql/0001_a.up.sql"],
+		"sql/0001_a.down.sql": migracoesTeste["sql/0001_a.down.sql"],
+		// O segundo comando falha depois de o primeiro ter criado a tabela
+		"sql/0002_c.up.sql":   {Data: []byte("CREATE TABLE c (id INT);\nINSERT INTO inexistente VALUES (1);\n")},
+		"sql/0002_c.down.sql": {Data: []byte("DROP TABLE c;\n")},
+	}
+	migrator, db := novoMigrator(t, fsys)
+
+	if _, err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), "0002_c") {
+		t.Fatalf("Up = %v, esperado o erro da migração 0002_c", err)
+	}
+
+	// O comando inteiro é desfeito, inclusive a 0001 aplicada antes da falha
+	if versao(t, migrator) != 0 {
+		t.Fatalf("versão %d depois da falha, esperado 0", versao(t, migrator))
+	}
+	if existeTabela(t, db, "a") || existeTabela(t, db, "c") {
+		t.Fatal("tabelas da migração que falhou continuam no banco")
+	}
+}
@@ -0,0 +1,586 @@
+package repository_test
+
+import (
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"comparei-servico-listas/internal/infrastructure/repository"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Suíte de contrato: as mesmas regras valem para todas as implementações de ListaRepository.
+// Memória e SQLite rodam sempre; o MySQL só quando MYSQL_TEST_DSN aponta para um banco de testes (mysql_test.go).
+
+type backend struct {
+	nome string
+	novo func(t *testing.T) interfaces.ListaRepository
+}
+
+var backends = []backend{
+	{"memory", func(t *testing.T) interfaces.ListaRepository {
+		return repository.NewMemoryRepository()
+	}},
+	{"sqlite", func(t *testing.T) interfaces.ListaRepository {
+		repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "listas.db"), 5*time.Second)
+		if err != nil {
+			t.Fatalf("NewSQLiteRepository: %v", err)
+		}
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	}},
+}
+
+// contrato roda o teste em cada backend disponível
+func contrato(t *testing.T, teste func(t *testing.T, repo interfaces.ListaRepository)) {
+	for _, b := range backends {
+		t.Run(b.nome, func(t *testing.T) {
+			teste(t, b.novo(t))
+		})
+	}
+}
+
+var sequencia atomic.Int64
+
+// novoUsuario gera um user_id que não se repete entre execuções, já que o banco do MySQL é reaproveitado
+func novoUsuario() string {
+	return fmt.Sprintf("u%x-%d", time.Now().UnixNano(), sequencia.Add(1))
+}
+
+func criaLista(t *testing.T, repo interfaces.ListaRepository, userID, nome string, status listas.StatusLista) int64 {
+	t.Helper()
+	ctx := context.Background()
+	id, err := repo.Create(ctx, &listas.Lista{UserID: userID, Nome: nome, Status: status})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	agora := time.Now()
+	err = repo.AddMembro(ctx, &listas.Membro{ListaID: id, UserID: userID, Papel: listas.PapelDono, ConvidadoPor: userID, AceitoEm: &agora})
+	if err != nil {
+		t.Fatalf("AddMembro: %v", err)
+	}
+	return id
+}
+
+func criaItem(t *testing.T, repo interfaces.ListaRepository, listaID, produtoID, mercadoID int64, preco float64, checked bool) int64 {
+	t.Helper()
+	item := &listas.ItemLista{ListaID: listaID, ProdutoID: produtoID, MercadoID: &mercadoID, Quantidade: 1, PrecoUnitario: preco, Checked: checked}
+	if err := repo.AddItem(context.Background(), item); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	return item.ID
+}
+
+func buscaItem(t *testing.T, repo interfaces.ListaRepository, itemID int64) *listas.ItemLista {
+	t.Helper()
+	item, err := repo.GetItem(context.Background(), itemID)
+	if err != nil {
+		t.Fatalf("GetItem: %v", err)
+	}
+	return item
+}
+
+func TestContratoRemocaoLogicaDeItens(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		listaID := criaLista(t, repo, novoUsuario(), "Mercado", listas.StatusAberta)
+		mantido := criaItem(t, repo, listaID, 1, 1, 10, false)
+		removido := criaItem(t, repo, listaID, 2, 1, 5, false)
+
+		if err := repo.RemoveItem(ctx, removido); err != nil {
+			t.Fatalf("RemoveItem: %v", err)
+		}
+		// Remover de novo não é erro
+		if err := repo.RemoveItem(ctx, removido); err != nil {
+			t.Fatalf("RemoveItem repetido: %v", err)
+		}
+
+		if item := buscaItem(t, repo, removido); item != nil {
+			t.Fatalf("GetItem devolveu item removido: %+v", item)
+		}
+
+		// Alterar um item removido não o traz de volta
+		err := repo.UpdateItem(ctx, &listas.ItemLista{ID: removido, ListaID: listaID, ProdutoID: 2, Quantidade: 3, PrecoUnitario: 7})
+		if err != nil {
+			t.Fatalf("UpdateItem em item removido: %v", err)
+		}
+		if item := buscaItem(t, repo, removido); item != nil {
+			t.Fatalf("UpdateItem reativou item removido: %+v", item)
+		}
+
+		lista, err := repo.FindByID(ctx, listaID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if len(lista.Itens) != 1 || lista.Itens[0].ID != mantido {
+			t.Fatalf("itens da lista = %+v, esperado só o item %d", lista.Itens, mantido)
+		}
+	})
+}
+
+func TestContratoListasAbertas(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		userID := novoUsuario()
+
+		aberta, err := repo.GetOpenList(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetOpenList: %v", err)
+		}
+		if aberta != nil {
+			t.Fatalf("GetOpenList sem listas = %+v, esperado nil", aberta)
+		}
+
+		criaLista(t, repo, userID, "Antiga", listas.StatusAberta)
+		criaLista(t, repo, userID, "Fechada", listas.StatusFechada)
+		criaLista(t, repo, userID, "Cancelada", listas.StatusCancelada)
+		// Criada no mesmo segundo da "Antiga": o desempate é pelo ID, a mais nova vence
+		recente := criaLista(t, repo, userID, "Recente", listas.StatusAberta)
+		criaItem(t, repo, recente, 1, 1, 10, false)
+		criaLista(t, repo, novoUsuario(), "De outro usuário", listas.StatusAberta)
+
+		abertas, err := repo.CountOpenLists(ctx, userID)
+		if err != nil {
+			t.Fatalf("CountOpenLists: %v", err)
+		}
+		if abertas != 2 {
+			t.Fatalf("CountOpenLists = %d, esperado 2", abertas)
+		}
+
+		aberta, err = repo.GetOpenList(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetOpenList: %v", err)
+		}
+		if aberta == nil || aberta.ID != recente {
+			t.Fatalf("GetOpenList = %+v, esperado a lista %d", aberta, recente)
+		}
+		if len(aberta.Itens) != 1 {
+			t.Fatalf("GetOpenList trouxe %d itens, esperado 1", len(aberta.Itens))
+		}
+	})
+}
+
+func TestContratoListaAbertaComoMembro(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		userID := novoUsuario()
+		propria := criaLista(t, repo, userID, "Própria", listas.StatusAberta)
+
+		// Compartilhadas depois da própria: a aceita é a mais recente em que ele é membro; o convite pendente não conta
+		agora := time.Now()
+		compartilhada := criaLista(t, repo, novoUsuario(), "Compartilhada", listas.StatusAberta)
+		err := repo.AddMembro(ctx, &listas.Membro{ListaID: compartilhada, UserID: userID, Papel: listas.PapelEditor, ConvidadoPor: "outro", AceitoEm: &agora})
+		if err != nil {
+			t.Fatalf("AddMembro: %v", err)
+		}
+		criaItem(t, repo, compartilhada, 1, 1, 10, false)
+		convite := criaLista(t, repo, novoUsuario(), "Convite", listas.StatusAberta)
+		if err := repo.AddMembro(ctx, &listas.Membro{ListaID: convite, UserID: userID, Papel: listas.PapelEditor, ConvidadoPor: "outro"}); err != nil {
+			t.Fatalf("AddMembro: %v", err)
+		}
+
+		atual, err := repo.GetOpenListMembro(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetOpenListMembro: %v", err)
+		}
+		if atual == nil || atual.ID != compartilhada || len(atual.Itens) != 1 {
+			t.Fatalf("GetOpenListMembro = %+v, esperado a lista %d com 1 item", atual, compartilhada)
+		}
+
+		// Para o limite de listas abertas só contam as que ele criou
+		aberta, err := repo.GetOpenList(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetOpenList: %v", err)
+		}
+		if aberta == nil || aberta.ID != propria {
+			t.Fatalf("GetOpenList = %+v, esperado a lista %d", aberta, propria)
+		}
+	})
+}
+
+func TestContratoAtualizacaoDePreco(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		// Produto e mercado próprios do teste, para não esbarrar em dados de outras execuções no MySQL
+		produto, mercado := time.Now().UnixNano()%1_000_000_000, int64(7)
+
+		aberta := criaLista(t, repo, novoUsuario(), "Aberta", listas.StatusAberta)
+		pendente := criaItem(t, repo, aberta, produto, mercado, 10, false)
+		comprado := criaItem(t, repo, aberta, produto, mercado, 10, true)
+		outroMercado := criaItem(t, repo, aberta, produto, mercado+1, 10, false)
+		removido := criaItem(t, repo, aberta, produto, mercado, 10, false)
+		if err := repo.RemoveItem(ctx, removido); err != nil {
+			t.Fatalf("RemoveItem: %v", err)
+		}
+
+		fechada := criaLista(t, repo, novoUsuario(), "Fechada", listas.StatusFechada)
+		daFechada := criaItem(t, repo, fechada, produto, mercado, 10, false)
+
+		outraAberta := criaLista(t, repo, novoUsuario(), "Outra aberta", listas.StatusAberta)
+		daOutra := criaItem(t, repo, outraAberta, produto, mercado, 10, false)
+
+		ids, err := repo.UpdatePriceInOpenLists(ctx, produto, mercado, 12.5)
+		if err != nil {
+			t.Fatalf("UpdatePriceInOpenLists: %v", err)
+		}
+		if want := []int64{aberta, outraAberta}; !reflect.DeepEqual(ids, want) {
+			t.Fatalf("listas afetadas = %v, esperado %v", ids, want)
+		}
+
+		precos := []struct {
+			nome   string
+			itemID int64
+			preco  float64
+		}{
+			{"pendente", pendente, 12.5},
+			{"pendente de outra lista", daOutra, 12.5},
+			{"comprado", comprado, 10},
+			{"outro mercado", outroMercado, 10},
+			{"lista fechada", daFechada, 10},
+		}
+		for _, p := range precos {
+			if item := buscaItem(t, repo, p.itemID); item.PrecoUnitario != p.preco {
+				t.Errorf("item %s: preço = %v, esperado %v", p.nome, item.PrecoUnitario, p.preco)
+			}
+		}
+
+		// Nenhum item elegível: nenhuma lista afetada
+		ids, err = repo.UpdatePriceInOpenLists(ctx, produto, mercado+2, 1)
+		if err != nil {
+			t.Fatalf("UpdatePriceInOpenLists sem itens: %v", err)
+		}
+		if len(ids) != 0 {
+			t.Fatalf("listas afetadas = %v, esperado nenhuma", ids)
+		}
+	})
+}
+
+func TestContratoPrecoMaisAntigo(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		produto, mercado := time.Now().UnixNano()%1_000_000_000, int64(7)
+		alterado := time.Date(2026, 10, 18, 12, 0, 0, 500_000_000, time.UTC)
+
+		salvos := []struct {
+			nome         string
+			preco        float64
+			modificadoEm time.Time
+			aceito       bool
+			guardado     float64
+		}{
+			{"primeiro", 12, alterado, true, 12},
+			{"mais antigo", 11, alterado.Add(-time.Minute), false, 12},
+			{"mesmo instante", 12.5, alterado, true, 12.5},
+			// As frações de segundo contam: o Promer pode alterar o preço duas vezes no mesmo segundo
+			{"antigo na fração de segundo", 11, alterado.Add(-300 * time.Millisecond), false, 12.5},
+			{"mais novo", 13, alterado.Add(time.Second), true, 13},
+			{"sem data", 14, time.Time{}, true, 14},
+		}
+		for _, s := range salvos {
+			aceito, err := repo.SavePrecoAtual(ctx, produto, mercado, s.preco, s.modificadoEm)
+			if err != nil {
+				t.Fatalf("%s: SavePrecoAtual: %v", s.nome, err)
+			}
+			preco, err := repo.GetPrecoAtual(ctx, produto, mercado)
+			if err != nil {
+				t.Fatalf("%s: GetPrecoAtual: %v", s.nome, err)
+			}
+			if aceito != s.aceito || preco == nil || *preco != s.guardado {
+				t.Fatalf("%s: aceito = %v, preço guardado = %v; esperado %v e %v", s.nome, aceito, preco, s.aceito, s.guardado)
+			}
+		}
+	})
+}
+
+func TestContratoUpdateStatus(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		userID := novoUsuario()
+		id := criaLista(t, repo, userID, "Mercado", listas.StatusAberta)
+
+		casos := []struct {
+			nome  string
+			lista listas.Lista
+			de    listas.StatusLista
+			erro  error
+		}{
+			{"status de diferente do atual", listas.Lista{ID: id, UserID: userID, Status: listas.StatusAberta}, listas.StatusFechada, listas.ErrTransicaoInvalida},
+			{"lista de outro usuário", listas.Lista{ID: id, UserID: novoUsuario(), Status: listas.StatusFechada}, listas.StatusAberta, listas.ErrListaNaoEncontrada},
+			{"lista inexistente", listas.Lista{ID: id + 1000, UserID: userID, Status: listas.StatusFechada}, listas.StatusAberta, listas.ErrListaNaoEncontrada},
+			{"transição válida", listas.Lista{ID: id, UserID: userID, Status: listas.StatusCancelada}, listas.StatusAberta, nil},
+			{"repetida depois de aplicada", listas.Lista{ID: id, UserID: userID, Status: listas.StatusCancelada}, listas.StatusAberta, listas.ErrTransicaoInvalida},
+		}
+		for _, c := range casos {
+			err := repo.UpdateStatus(ctx, &c.lista, c.de)
+			if !errors.Is(err, c.erro) || (c.erro == nil && err != nil) {
+				t.Errorf("%s: erro = %v, esperado %v", c.nome, err, c.erro)
+			}
+		}
+
+		lista, err := repo.FindByID(ctx, id)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if lista.Status != listas.StatusCancelada {
+			t.Fatalf("status = %s, esperado %s", lista.Status, listas.StatusCancelada)
+		}
+	})
+}
+
+func TestContratoPaginacaoPorCursor(t *testing.T) {
+	// Maiúsculas e minúsculas misturadas: a ordem por nome não as diferencia em nenhum backend
+	nomes := []string{"banana", "Abacate", "cenoura", "Damasco", "erva-doce"}
+	esperado := []string{"Abacate", "banana", "cenoura", "Damasco", "erva-doce"}
+
+	ordenacoes := []struct {
+		ordem    listas.Ordenacao
+		esperado []string
+	}{
+		{listas.Ordenacao{Campo: listas.OrdenaNome}, esperado},
+		{listas.Ordenacao{Campo: listas.OrdenaNome, Desc: true}, inverte(esperado)},
+		// Criadas no mesmo segundo: o ID desempata, então a ordem é a de criação
+		{listas.Ordenacao{Campo: listas.OrdenaCriacao}, nomes},
+		{listas.Ordenacao{Campo: listas.OrdenaCriacao, Desc: true}, inverte(nomes)},
+	}
+
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		userID := novoUsuario()
+		for _, nome := range nomes {
+			criaLista(t, repo, userID, nome, listas.StatusAberta)
+		}
+
+		// Convite pendente em lista de outro usuário: não aparece até ser aceito
+		convite := criaLista(t, repo, novoUsuario(), "Convite", listas.StatusAberta)
+		err := repo.AddMembro(ctx, &listas.Membro{ListaID: convite, UserID: userID, Papel: listas.PapelEditor, ConvidadoPor: "outro"})
+		if err != nil {
+			t.Fatalf("AddMembro: %v", err)
+		}
+
+		for _, o := range ordenacoes {
+			filtro := listas.FiltroListas{Ordenacao: o.ordem, Limite: 2}
+			if err := filtro.Normaliza(); err != nil {
+				t.Fatalf("Normaliza: %v", err)
+			}
+
+			var vistos []string
+			for pagina := 1; ; pagina++ {
+				resultado, err := repo.GetAll(ctx, userID, filtro)
+				if err != nil {
+					t.Fatalf("%v, página %d: GetAll: %v", o.ordem, pagina, err)
+				}
+				if resultado.Total != len(nomes) {
+					t.Fatalf("%v, página %d: Total = %d, esperado %d", o.ordem, pagina, resultado.Total, len(nomes))
+				}
+				for _, l := range resultado.Listas {
+					vistos = append(vistos, l.Nome)
+				}
+				if resultado.NextCursor == nil {
+					break
+				}
+				if filtro.Cursor, err = listas.DecodeCursor(*resultado.NextCursor); err != nil {
+					t.Fatalf("DecodeCursor: %v", err)
+				}
+			}
+
+			if !reflect.DeepEqual(vistos, o.esperado) {
+				t.Errorf("%v: ordem = %v, esperado %v", o.ordem, vistos, o.esperado)
+			}
+		}
+	})
+}
+
+func TestContratoWithTx(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		userID := novoUsuario()
+		falha := errors.New("falha no meio da transação")
+
+		var desfeita int64
+		err := repo.WithTx(ctx, func(tx interfaces.ListaRepository) error {
+			desfeita = criaLista(t, tx, userID, "Desfeita", listas.StatusAberta)
+			criaItem(t, tx, desfeita, 1, 1, 10, false)
+			return falha
+		})
+		if !errors.Is(err, falha) {
+			t.Fatalf("WithTx: erro = %v, esperado o erro de fn", err)
+		}
+
+		lista, err := repo.FindByID(ctx, desfeita)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if lista != nil {
+			t.Fatalf("lista criada na transação desfeita continua existindo: %+v", lista)
+		}
+		if abertas, _ := repo.CountOpenLists(ctx, userID); abertas != 0 {
+			t.Fatalf("CountOpenLists = %d depois do rollback, esperado 0", abertas)
+		}
+
+		var confirmada int64
+		err = repo.WithTx(ctx, func(tx interfaces.ListaRepository) error {
+			confirmada = criaLista(t, tx, userID, "Confirmada", listas.StatusAberta)
+			criaItem(t, tx, confirmada, 1, 1, 10, false)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+		lista, err = repo.FindByID(ctx, confirmada)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if lista == nil || len(lista.Itens) != 1 {
+			t.Fatalf("lista confirmada = %+v, esperado a lista com 1 item", lista)
+		}
+	})
+}
+
+func TestContratoTravaUsuario(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		userID := novoUsuario()
+
+		// Cada transação só cria a lista se o usuário ainda não tiver uma aberta
+		const tentativas = 5
+		erros := make(chan error, tentativas)
+		var wg sync.WaitGroup
+		for i := 0; i < tentativas; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				erros <- repo.WithTx(ctx, func(tx interfaces.ListaRepository) error {
+					if err := tx.TravaUsuario(ctx, userID); err != nil {
+						return err
+					}
+					abertas, err := tx.CountOpenLists(ctx, userID)
+					if err != nil || abertas > 0 {
+						return err
+					}
+					_, err = tx.Create(ctx, &listas.Lista{UserID: userID, Nome: "Concorrente", Status: listas.StatusAberta})
+					return err
+				})
+			}()
+		}
+		wg.Wait()
+		close(erros)
+
+		for err := range erros {
+			if err != nil {
+				t.Fatalf("WithTx: %v", err)
+			}
+		}
+		if abertas, _ := repo.CountOpenLists(ctx, userID); abertas != 1 {
+			t.Fatalf("CountOpenLists = %d depois das criações simultâneas, esperado 1", abertas)
+		}
+	})
+}
+
+func TestContratoOutboxEventoRepetido(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		// ListaID único para não esbarrar em mensagens de execuções anteriores no MySQL
+		compra := listas.ItemComprado{ListaID: time.Now().UnixNano(), ItemID: 1, UserID: novoUsuario(), ProdutoID: 1, PrecoUnitario: 10, Quantidade: 1}
+
+		primeira, err := listas.NovaMensagemOutbox(compra)
+		if err != nil {
+			t.Fatalf("NovaMensagemOutbox: %v", err)
+		}
+		if err := repo.InsertOutbox(ctx, primeira); err != nil {
+			t.Fatalf("InsertOutbox: %v", err)
+		}
+
+		// O UserID não faz parte da chave: outro membro marcando o mesmo item é a mesma compra
+		compra.UserID = novoUsuario()
+		repetida, err := listas.NovaMensagemOutbox(compra)
+		if err != nil {
+			t.Fatalf("NovaMensagemOutbox: %v", err)
+		}
+		if repetida.EventoID != primeira.EventoID {
+			t.Fatalf("EventoID = %s, esperado o mesmo da primeira mensagem (%s)", repetida.EventoID, primeira.EventoID)
+		}
+		if err := repo.InsertOutbox(ctx, repetida); err != nil {
+			t.Fatalf("InsertOutbox repetido: %v", err)
+		}
+		if repetida.ID != primeira.ID {
+			t.Fatalf("ID = %d, esperado o da mensagem já gravada (%d)", repetida.ID, primeira.ID)
+		}
+
+		// Enviada a mensagem gravada, não resta outra pendente com o mesmo EventoID
+		if buscaPendente(t, repo, time.Now().Add(time.Hour), primeira.ID) == nil {
+			t.Fatalf("mensagem %d não está pendente", primeira.ID)
+		}
+		if err := repo.MarcaOutboxEnviada(ctx, primeira.ID, time.Now()); err != nil {
+			t.Fatalf("MarcaOutboxEnviada: %v", err)
+		}
+		pendentes, err := repo.GetOutboxPendentes(ctx, time.Now().Add(time.Hour), 1000)
+		if err != nil {
+			t.Fatalf("GetOutboxPendentes: %v", err)
+		}
+		for _, m := range pendentes {
+			if m.EventoID == primeira.EventoID {
+				t.Fatalf("mensagem repetida gravada: %+v", m)
+			}
+		}
+	})
+}
+
+func TestContratoOutboxReserva(t *testing.T) {
+	contrato(t, func(t *testing.T, repo interfaces.ListaRepository) {
+		ctx := context.Background()
+		msg, err := listas.NovaMensagemOutbox(listas.LogEvento{UserID: novoUsuario(), Acao: "TESTE"})
+		if err != nil {
+			t.Fatalf("NovaMensagemOutbox: %v", err)
+		}
+		if err := repo.InsertOutbox(ctx, msg); err != nil {
+			t.Fatalf("InsertOutbox: %v", err)
+		}
+
+		agora := time.Now().Add(time.Minute)
+		if err := repo.ReservaOutbox(ctx, msg.ID, agora.Add(time.Minute)); err != nil {
+			t.Fatalf("ReservaOutbox: %v", err)
+		}
+		if buscaPendente(t, repo, agora, msg.ID) != nil {
+			t.Fatalf("mensagem reservada voltou em GetOutboxPendentes antes da reserva vencer")
+		}
+		pendente := buscaPendente(t, repo, agora.Add(2*time.Minute), msg.ID)
+		if pendente == nil {
+			t.Fatalf("mensagem não voltou em GetOutboxPendentes depois da reserva vencer")
+		}
+		if pendente.Tentativas != 0 {
+			t.Fatalf("Tentativas = %d depois da reserva, esperado 0", pendente.Tentativas)
+		}
+	})
+}
+
+// buscaPendente procura a mensagem entre as pendentes em "agora". Para não acumular pendências entre execuções
+// no MySQL, a mensagem é marcada como enviada no fim do teste.
+func buscaPendente(t *testing.T, repo interfaces.ListaRepository, agora time.Time, id int64) *listas.MensagemOutbox {
+	t.Helper()
+	t.Cleanup(func() { repo.MarcaOutboxEnviada(context.Background(), id, time.Now()) })
+
+	pendentes, err := repo.GetOutboxPendentes(context.Background(), agora, 1000)
+	if err != nil {
+		t.Fatalf("GetOutboxPendentes: %v", err)
+	}
+	for _, m := range pendentes {
+		if m.ID == id {
+			return &m
+		}
+	}
+	return nil
+}
+
+func inverte(s []string) []string {
+	r := make([]string, len(s))
+	for i, v := range s {
+		r[len(s)-1-i] = v
+	}
+	return r
+}
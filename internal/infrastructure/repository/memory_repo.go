@@ -0,0 +1,718 @@
+package repository
+
+import (
+	"cmp"
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository guarda listas e itens em memória, com as mesmas regras do MySQLRepository
+// (remoção lógica de itens, filtro de listas abertas, atualização de preço só em itens não comprados).
+// Serve para testes e para rodar o serviço localmente sem banco.
+type MemoryRepository struct {
+	mu    *sync.Mutex
+	dados *memoryData
+	emTx  bool // dentro de WithTx o lock já está com a transação
+}
+
+type memoryData struct {
+	listas    map[int64]listas.Lista // sem os itens, que ficam em itens
+	itens     map[int64]memoryItem
+	auditoria []listas.Auditoria
+	membros   map[membroKey]listas.Membro
+	links     map[int64]listas.LinkCompartilhamento
+	precos    map[precoKey]precoMercado
+	outbox    map[int64]listas.MensagemOutbox
+
+	ultimaListaID     int64
+	ultimoItemID      int64
+	ultimaAuditoriaID int64
+	ultimoLinkID      int64
+	ultimoOutboxID    int64
+}
+
+type memoryItem struct {
+	item      listas.ItemLista
+	deletedAt *time.Time
+}
+
+type membroKey struct {
+	listaID int64
+	userID  string
+}
+
+type precoKey struct {
+	produtoID int64
+	mercadoID int64
+}
+
+// precoMercado é o último preço recebido e quando ele foi alterado no Promer
+type precoMercado struct {
+	preco        float64
+	modificadoEm time.Time
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		mu: &sync.Mutex{},
+		dados: &memoryData{
+			listas:  map[int64]listas.Lista{},
+			itens:   map[int64]memoryItem{},
+			membros: map[membroKey]listas.Membro{},
+			links:   map[int64]listas.LinkCompartilhamento{},
+			precos:  map[precoKey]precoMercado{},
+			outbox:  map[int64]listas.MensagemOutbox{},
+		},
+	}
+}
+
+// lock trava o repositório, exceto dentro de WithTx, em que a transação já detém o lock
+func (r *MemoryRepository) lock() func() {
+	if r.emTx {
+		return func() {}
+	}
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// clone copia o estado para permitir o rollback de WithTx
+func (d *memoryData) clone() *memoryData {
+	c := *d
+	c.listas = make(map[int64]listas.Lista, len(d.listas))
+	for id, l := range d.listas {
+		c.listas[id] = l
+	}
+	c.itens = make(map[int64]memoryItem, len(d.itens))
+	for id, i := range d.itens {
+		c.itens[id] = i
+	}
+	c.auditoria = append([]listas.Auditoria(nil), d.auditoria...)
+	c.membros = make(map[membroKey]listas.Membro, len(d.membros))
+	for k, m := range d.membros {
+		c.membros[k] = m
+	}
+	c.links = make(map[int64]listas.LinkCompartilhamento, len(d.links))
+	for id, l := range d.links {
+		c.links[id] = l
+	}
+	c.precos = make(map[precoKey]precoMercado, len(d.precos))
+	for k, p := range d.precos {
+		c.precos[k] = p
+	}
+	c.outbox = make(map[int64]listas.MensagemOutbox, len(d.outbox))
+	for id, m := range d.outbox {
+		c.outbox[id] = m
+	}
+	return &c
+}
+
+// --- Transações ---
+
+// WithTx serializa a transação com as demais operações e restaura o estado anterior se fn falhar
+func (r *MemoryRepository) WithTx(ctx context.Context, fn func(repo interfaces.ListaRepository) error) error {
+	if r.emTx {
+		return fn(r)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := r.dados.clone()
+	if err := fn(&MemoryRepository{mu: r.mu, dados: r.dados, emTx: true}); err != nil {
+		*r.dados = *snapshot
+		return err
+	}
+	return nil
+}
+
+// TravaUsuario não faz nada: WithTx já segura o mutex do repositório durante toda a transação
+func (r *MemoryRepository) TravaUsuario(ctx context.Context, userID string) error {
+	return nil
+}
+
+// --- Listas ---
+
+func (r *MemoryRepository) CountOpenLists(ctx context.Context, userID string) (int, error) {
+	defer r.lock()()
+
+	count := 0
+	for _, l := range r.dados.listas {
+		if l.UserID == userID && l.Status == listas.StatusAberta {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryRepository) GetOpenList(ctx context.Context, userID string) (*listas.Lista, error) {
+	defer r.lock()()
+
+	l := r.openList(userID, false)
+	if l != nil {
+		l.Itens = r.itemsByListaID(l.ID)
+	}
+	return l, nil
+}
+
+func (r *MemoryRepository) GetOpenListMembro(ctx context.Context, userID string) (*listas.Lista, error) {
+	defer r.lock()()
+
+	l := r.openList(userID, true)
+	if l != nil {
+		l.Itens = r.itemsByListaID(l.ID)
+	}
+	return l, nil
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, lista *listas.Lista) (int64, error) {
+	defer r.lock()()
+
+	r.dados.ultimaListaID++
+	agora := time.Now()
+
+	nova := *lista
+	nova.ID = r.dados.ultimaListaID
+	nova.Itens = nil
+	nova.CreatedAt = agora
+	nova.UpdatedAt = agora
+	r.dados.listas[nova.ID] = nova
+
+	return nova.ID, nil
+}
+
+func (r *MemoryRepository) FindByID(ctx context.Context, id int64) (*listas.Lista, error) {
+	defer r.lock()()
+
+	l, ok := r.dados.listas[id]
+	if !ok {
+		return nil, nil
+	}
+	l.Itens = r.itemsByListaID(id)
+	return &l, nil
+}
+
+func (r *MemoryRepository) UpdateStatus(ctx context.Context, lista *listas.Lista, de listas.StatusLista) error {
+	defer r.lock()()
+
+	l, ok := r.dados.listas[lista.ID]
+	if !ok || l.UserID != lista.UserID {
+		return listas.ErrListaNaoEncontrada
+	}
+	if l.Status != de {
+		return listas.ErrTransicaoInvalida
+	}
+
+	l.Status = lista.Status
+	l.TotalPrevisto = lista.TotalPrevisto
+	l.TotalFinal = lista.TotalFinal
+	l.FinalizadaEm = lista.FinalizadaEm
+	l.UpdatedAt = time.Now()
+	r.dados.listas[l.ID] = l
+	return nil
+}
+
+func (r *MemoryRepository) GetAll(ctx context.Context, userID string, filtro listas.FiltroListas) (*listas.PaginaListas, error) {
+	defer r.lock()()
+
+	nome := strings.ToLower(filtro.Nome)
+	var resumos []*listas.ResumoLista
+	for _, l := range r.dados.listas {
+		membro, ok := r.dados.membros[membroKey{l.ID, userID}]
+		if !ok || !membro.Ativo() {
+			continue
+		}
+		if filtro.Status != nil && l.Status != *filtro.Status {
+			continue
+		}
+		if nome != "" && !strings.Contains(strings.ToLower(l.Nome), nome) {
+			continue
+		}
+		if filtro.CriadaDe != nil && l.CreatedAt.Before(*filtro.CriadaDe) {
+			continue
+		}
+		if filtro.CriadaAte != nil && !l.CreatedAt.Before(*filtro.CriadaAte) {
+			continue
+		}
+		l.Itens = r.itemsByListaID(l.ID)
+		resumo := listas.ResumoDe(&l)
+		resumo.Papel = membro.Papel
+		resumos = append(resumos, resumo)
+	}
+	total := len(resumos)
+
+	sort.Slice(resumos, func(a, b int) bool { return comparaListas(resumos[a], resumos[b], filtro.Ordenacao) < 0 })
+
+	if filtro.Cursor != nil {
+		// Pula tudo que vem até o cursor, inclusive a própria lista do cursor
+		pos := sort.Search(len(resumos), func(i int) bool {
+			return comparaCursor(resumos[i], filtro.Cursor, filtro.Ordenacao) > 0
+		})
+		resumos = resumos[pos:]
+	}
+	if len(resumos) > filtro.Limite+1 {
+		resumos = resumos[:filtro.Limite+1]
+	}
+	return listas.NovaPagina(resumos, total, filtro), nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, lista *listas.Lista) error {
+	defer r.lock()()
+
+	l, ok := r.dados.listas[lista.ID]
+	if !ok || l.UserID != lista.UserID {
+		return nil
+	}
+
+	l.Nome = lista.Nome
+	l.Status = lista.Status
+	l.TotalPrevisto = lista.TotalPrevisto
+	l.TotalFinal = lista.TotalFinal
+	l.UpdatedAt = time.Now()
+	r.dados.listas[l.ID] = l
+	return nil
+}
+
+// --- Auditoria ---
+
+func (r *MemoryRepository) InsertAuditoria(ctx context.Context, auditoria *listas.Auditoria) error {
+	defer r.lock()()
+
+	r.dados.ultimaAuditoriaID++
+	auditoria.ID = r.dados.ultimaAuditoriaID
+	auditoria.CreatedAt = time.Now()
+	r.dados.auditoria = append(r.dados.auditoria, *auditoria)
+	return nil
+}
+
+// --- Itens ---
+
+func (r *MemoryRepository) AddItem(ctx context.Context, item *listas.ItemLista) error {
+	defer r.lock()()
+
+	r.dados.ultimoItemID++
+	item.ID = r.dados.ultimoItemID
+
+	// Mesmas colunas do INSERT no MySQL: o snapshot da finalização começa vazio
+	novo := *item
+	novo.PrecoPago = nil
+	novo.MercadoPagoID = nil
+	r.dados.itens[novo.ID] = memoryItem{item: novo}
+	return nil
+}
+
+func (r *MemoryRepository) RemoveItem(ctx context.Context, itemID int64) error {
+	defer r.lock()()
+
+	i, ok := r.dados.itens[itemID]
+	if !ok {
+		return nil
+	}
+	agora := time.Now()
+	i.deletedAt = &agora
+	r.dados.itens[itemID] = i
+	return nil
+}
+
+func (r *MemoryRepository) UpdateItem(ctx context.Context, item *listas.ItemLista) error {
+	defer r.lock()()
+
+	i, ok := r.dados.itens[item.ID]
+	if !ok || i.deletedAt != nil {
+		return nil
+	}
+
+	i.item.Quantidade = item.Quantidade
+	i.item.PrecoUnitario = item.PrecoUnitario
+	i.item.Checked = item.Checked
+	i.item.CheckedBy = item.CheckedBy
+	i.item.MercadoID = item.MercadoID
+	i.item.PrecoPago = item.PrecoPago
+	i.item.MercadoPagoID = item.MercadoPagoID
+	r.dados.itens[item.ID] = i
+	return nil
+}
+
+func (r *MemoryRepository) GetItem(ctx context.Context, itemID int64) (*listas.ItemLista, error) {
+	defer r.lock()()
+
+	i, ok := r.dados.itens[itemID]
+	if !ok || i.deletedAt != nil {
+		return nil, nil
+	}
+	item := i.item
+	return &item, nil
+}
+
+// --- Membros ---
+
+func (r *MemoryRepository) AddMembro(ctx context.Context, membro *listas.Membro) error {
+	defer r.lock()()
+
+	novo := *membro
+	novo.CreatedAt = time.Now()
+	r.dados.membros[membroKey{novo.ListaID, novo.UserID}] = novo
+	return nil
+}
+
+func (r *MemoryRepository) GetMembro(ctx context.Context, listaID int64, userID string) (*listas.Membro, error) {
+	defer r.lock()()
+
+	m, ok := r.dados.membros[membroKey{listaID, userID}]
+	if !ok {
+		return nil, nil
+	}
+	return &m, nil
+}
+
+func (r *MemoryRepository) GetMembros(ctx context.Context, listaID int64) ([]listas.Membro, error) {
+	defer r.lock()()
+
+	var membros []listas.Membro
+	for _, m := range r.dados.membros {
+		if m.ListaID == listaID {
+			membros = append(membros, m)
+		}
+	}
+	sort.Slice(membros, func(a, b int) bool {
+		if !membros[a].CreatedAt.Equal(membros[b].CreatedAt) {
+			return membros[a].CreatedAt.Before(membros[b].CreatedAt)
+		}
+		return membros[a].UserID < membros[b].UserID
+	})
+	return membros, nil
+}
+
+func (r *MemoryRepository) GetConvitesPendentes(ctx context.Context, userID string) ([]listas.Membro, error) {
+	defer r.lock()()
+
+	var convites []listas.Membro
+	for _, m := range r.dados.membros {
+		if _, ok := r.dados.listas[m.ListaID]; ok && m.UserID == userID && !m.Ativo() {
+			convites = append(convites, m)
+		}
+	}
+	sort.Slice(convites, func(a, b int) bool {
+		if !convites[a].CreatedAt.Equal(convites[b].CreatedAt) {
+			return convites[a].CreatedAt.After(convites[b].CreatedAt)
+		}
+		return convites[a].ListaID > convites[b].ListaID
+	})
+	return convites, nil
+}
+
+func (r *MemoryRepository) AceitaConvite(ctx context.Context, listaID int64, userID string, em time.Time) error {
+	defer r.lock()()
+
+	m, ok := r.dados.membros[membroKey{listaID, userID}]
+	if !ok || m.Ativo() {
+		return nil
+	}
+	m.AceitoEm = &em
+	r.dados.membros[membroKey{listaID, userID}] = m
+	return nil
+}
+
+func (r *MemoryRepository) RemoveMembro(ctx context.Context, listaID int64, userID string) error {
+	defer r.lock()()
+
+	delete(r.dados.membros, membroKey{listaID, userID})
+	return nil
+}
+
+// --- Links de compartilhamento ---
+
+func (r *MemoryRepository) CreateLink(ctx context.Context, link *listas.LinkCompartilhamento) error {
+	defer r.lock()()
+
+	r.dados.ultimoLinkID++
+	link.ID = r.dados.ultimoLinkID
+	link.CreatedAt = time.Now().Truncate(time.Second)
+	r.dados.links[link.ID] = *link
+	return nil
+}
+
+func (r *MemoryRepository) GetLink(ctx context.Context, id int64) (*listas.LinkCompartilhamento, error) {
+	defer r.lock()()
+
+	l, ok := r.dados.links[id]
+	if !ok {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+func (r *MemoryRepository) GetLinksAtivos(ctx context.Context, listaID int64, agora time.Time) ([]listas.LinkCompartilhamento, error) {
+	defer r.lock()()
+
+	var links []listas.LinkCompartilhamento
+	for _, l := range r.dados.links {
+		if l.ListaID == listaID && l.Valido(agora) {
+			links = append(links, l)
+		}
+	}
+	sort.Slice(links, func(a, b int) bool { return links[a].ID > links[b].ID })
+	return links, nil
+}
+
+func (r *MemoryRepository) RevogaLink(ctx context.Context, id int64, em time.Time) error {
+	defer r.lock()()
+
+	l, ok := r.dados.links[id]
+	if !ok || l.RevogadoEm != nil {
+		return nil
+	}
+	l.RevogadoEm = &em
+	r.dados.links[id] = l
+	return nil
+}
+
+// --- Atualização em Massa (RF4) ---
+
+func (r *MemoryRepository) UpdatePriceInOpenLists(ctx context.Context, produtoID int64, mercadoID int64, novoPreco float64) ([]int64, error) {
+	defer r.lock()()
+
+	afetadas := map[int64]bool{}
+	for id, i := range r.dados.itens {
+		if i.deletedAt != nil || i.item.Checked || i.item.ProdutoID != produtoID {
+			continue
+		}
+		if i.item.MercadoID == nil || *i.item.MercadoID != mercadoID {
+			continue
+		}
+		if r.dados.listas[i.item.ListaID].Status != listas.StatusAberta {
+			continue
+		}
+
+		i.item.PrecoUnitario = novoPreco
+		r.dados.itens[id] = i
+		afetadas[i.item.ListaID] = true
+	}
+
+	var listaIDs []int64
+	for id := range afetadas {
+		listaIDs = append(listaIDs, id)
+	}
+	sort.Slice(listaIDs, func(a, b int) bool { return listaIDs[a] < listaIDs[b] })
+	return listaIDs, nil
+}
+
+func (r *MemoryRepository) SavePrecoAtual(ctx context.Context, produtoID int64, mercadoID int64, preco float64, modificadoEm time.Time) (bool, error) {
+	defer r.lock()()
+
+	chave := precoKey{produtoID, mercadoID}
+	guardado, ok := r.dados.precos[chave]
+	if ok && !guardado.modificadoEm.IsZero() && !modificadoEm.IsZero() && modificadoEm.Before(guardado.modificadoEm) {
+		return false, nil
+	}
+	r.dados.precos[chave] = precoMercado{preco: preco, modificadoEm: modificadoEm}
+	return true, nil
+}
+
+func (r *MemoryRepository) GetPrecoAtual(ctx context.Context, produtoID int64, mercadoID int64) (*float64, error) {
+	defer r.lock()()
+
+	guardado, ok := r.dados.precos[precoKey{produtoID, mercadoID}]
+	if !ok {
+		return nil, nil
+	}
+	return &guardado.preco, nil
+}
+
+// --- Outbox ---
+
+func (r *MemoryRepository) InsertOutbox(ctx context.Context, msg *listas.MensagemOutbox) error {
+	defer r.lock()()
+
+	for _, m := range r.dados.outbox {
+		if m.EventoID == msg.EventoID {
+			msg.ID = m.ID
+			return nil
+		}
+	}
+
+	r.dados.ultimoOutboxID++
+	msg.ID = r.dados.ultimoOutboxID
+	msg.CreatedAt = time.Now().Truncate(time.Second)
+	msg.ProximaTentativa = msg.CreatedAt
+	r.dados.outbox[msg.ID] = *msg
+	return nil
+}
+
+func (r *MemoryRepository) GetOutboxPendentes(ctx context.Context, agora time.Time, limite int) ([]listas.MensagemOutbox, error) {
+	defer r.lock()()
+
+	var msgs []listas.MensagemOutbox
+	for _, m := range r.dados.outbox {
+		if m.EnviadoEm == nil && !m.ProximaTentativa.After(agora) {
+			msgs = append(msgs, m)
+		}
+	}
+	sort.Slice(msgs, func(a, b int) bool { return msgs[a].ID < msgs[b].ID })
+	if len(msgs) > limite {
+		msgs = msgs[:limite]
+	}
+	return msgs, nil
+}
+
+func (r *MemoryRepository) ReservaOutbox(ctx context.Context, id int64, ate time.Time) error {
+	defer r.lock()()
+
+	m, ok := r.dados.outbox[id]
+	if !ok {
+		return nil
+	}
+	m.ProximaTentativa = ate
+	r.dados.outbox[id] = m
+	return nil
+}
+
+func (r *MemoryRepository) MarcaOutboxEnviada(ctx context.Context, id int64, em time.Time) error {
+	defer r.lock()()
+
+	m, ok := r.dados.outbox[id]
+	if !ok {
+		return nil
+	}
+	m.EnviadoEm = &em
+	r.dados.outbox[id] = m
+	return nil
+}
+
+func (r *MemoryRepository) MarcaOutboxFalha(ctx context.Context, id int64, erro string, proximaTentativa time.Time) error {
+	defer r.lock()()
+
+	m, ok := r.dados.outbox[id]
+	if !ok {
+		return nil
+	}
+	m.Tentativas++
+	m.UltimoErro = &erro
+	m.ProximaTentativa = proximaTentativa
+	r.dados.outbox[id] = m
+	return nil
+}
+
+func (r *MemoryRepository) GetBacklogOutbox(ctx context.Context) (*listas.BacklogOutbox, error) {
+	defer r.lock()()
+
+	backlog := &listas.BacklogOutbox{}
+	var maisAntiga int64
+	for id, m := range r.dados.outbox {
+		if m.EnviadoEm != nil {
+			continue
+		}
+		backlog.Pendentes++
+		if maisAntiga == 0 || id < maisAntiga {
+			maisAntiga = id
+		}
+	}
+	if maisAntiga != 0 {
+		criada := r.dados.outbox[maisAntiga].CreatedAt
+		backlog.MaisAntiga = &criada
+	}
+	return backlog, nil
+}
+
+func (r *MemoryRepository) RemoveOutboxEnviadas(ctx context.Context, antes time.Time) (int64, error) {
+	defer r.lock()()
+
+	var removidas int64
+	for id, m := range r.dados.outbox {
+		if m.EnviadoEm != nil && m.EnviadoEm.Before(antes) {
+			delete(r.dados.outbox, id)
+			removidas++
+		}
+	}
+	return removidas, nil
+}
+
+// --- Auxiliares (chamados com o lock já obtido) ---
+
+// openList segue o ORDER BY created_at DESC LIMIT 1 do MySQL
+// openList retorna a lista ABERTA mais recente do usuário: as que ele criou ou, com comoMembro, as em que é membro ativo
+func (r *MemoryRepository) openList(userID string, comoMembro bool) *listas.Lista {
+	var abertas []*listas.Lista
+	for _, l := range r.dados.listas {
+		entra := l.UserID == userID
+		if comoMembro {
+			membro, ok := r.dados.membros[membroKey{l.ID, userID}]
+			entra = ok && membro.Ativo()
+		}
+		if entra && l.Status == listas.StatusAberta {
+			copia := l
+			abertas = append(abertas, &copia)
+		}
+	}
+	if len(abertas) == 0 {
+		return nil
+	}
+	sortRecentes(abertas)
+	return abertas[0]
+}
+
+func (r *MemoryRepository) itemsByListaID(listaID int64) []listas.ItemLista {
+	var itens []listas.ItemLista
+	for _, i := range r.dados.itens {
+		if i.item.ListaID == listaID && i.deletedAt == nil {
+			itens = append(itens, i.item)
+		}
+	}
+	sort.Slice(itens, func(a, b int) bool { return itens[a].ID < itens[b].ID })
+	return itens
+}
+
+// comparaListas segue o ORDER BY campo, id do MySQL: negativo se a vem antes de b
+func comparaListas(a, b *listas.ResumoLista, o listas.Ordenacao) int {
+	return comparaCursor(a, listas.CursorDe(b, o), o)
+}
+
+// comparaCursor compara l com a posição do cursor na ordenação o
+func comparaCursor(l *listas.ResumoLista, c *listas.Cursor, o listas.Ordenacao) int {
+	var r int
+	if o.Campo == listas.OrdenaNome {
+		r = comparaNomes(l.Nome, c.Valor)
+	} else {
+		t, _ := c.Data()
+		valor := l.CreatedAt
+		if o.Campo == listas.OrdenaAtualizacao {
+			valor = l.UpdatedAt
+		}
+		r = valor.Compare(t)
+	}
+	if r == 0 {
+		r = cmp.Compare(l.ID, c.ID)
+	}
+	if o.Desc {
+		return -r
+	}
+	return r
+}
+
+// comparaNomes ignora maiúsculas e minúsculas só nas letras ASCII, como o COLLATE NOCASE do SQLite
+func comparaNomes(a, b string) int {
+	minusculas := func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}
+	return strings.Compare(strings.Map(minusculas, a), strings.Map(minusculas, b))
+}
+
+// sortRecentes ordena por created_at DESC, desempatando pelo ID
+func sortRecentes(listasArr []*listas.Lista) {
+	sort.Slice(listasArr, func(a, b int) bool {
+		if !listasArr[a].CreatedAt.Equal(listasArr[b].CreatedAt) {
+			return listasArr[a].CreatedAt.After(listasArr[b].CreatedAt)
+		}
+		return listasArr[a].ID > listasArr[b].ID
+	})
+}
@@ -0,0 +1,41 @@
+package repository_test
+
+import (
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/infrastructure/migration"
+	"comparei-servico-listas/internal/infrastructure/repository"
+	"comparei-servico-listas/migrations"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// Com MYSQL_TEST_DSN (ex.: "root:root@tcp(localhost:3306)/listas_test?parseTime=true") a suíte de contrato
+// também roda no MySQL. O banco é migrado para a versão mais nova e os dados dos testes não são apagados.
+func init() {
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		return
+	}
+
+	backends = append(backends, backend{"mysql", func(t *testing.T) interfaces.ListaRepository {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			t.Fatalf("sql.Open: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := migration.New(db, migrations.MySQL, "mysql", migration.LockMySQL(30*time.Second))
+		if err != nil {
+			t.Fatalf("migration.New: %v", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatalf("migrate up: %v", err)
+		}
+		return repository.NewMySQLRepository(db, 5*time.Second)
+	}})
+}
@@ -0,0 +1,781 @@
+package repository
+
+import (
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+)
+
+// sqlRepository implementa ListaRepository sobre database/sql. As consultas são escritas para
+// funcionar tanto no MySQL quanto no SQLite; o que muda entre os bancos fica em dialeto.
+type sqlRepository struct {
+	db      *sql.DB
+	q       querier       // *sql.DB fora de transação, *sql.Tx dentro de WithTx
+	timeout time.Duration // limite de cada operação; zero desativa
+	dialeto dialeto
+}
+
+// dialeto reúne os trechos de SQL que não são portáveis entre os bancos suportados
+type dialeto struct {
+	// lockLinhas é adicionado ao fim de SELECTs que precisam travar as linhas lidas dentro da transação
+	lockLinhas string
+	// lockPulando trava as linhas lidas, pulando as já travadas por outra transação (fila entre réplicas)
+	lockPulando string
+	// data converte um time.Time no parâmetro que o banco compara com as colunas TIMESTAMP
+	data func(t time.Time) any
+	// upsertPreco grava (produto_id, mercado_id, preco_unitario, modificado_em) em precos_mercado, sobrescrevendo o preço existente
+	upsertPreco string
+	// colacaoNome é adicionada à coluna nome na ordenação e no cursor, para que maiúsculas e minúsculas
+	// fiquem juntas como na colação padrão do MySQL
+	colacaoNome string
+	// ignoraOutboxRepetida é adicionado ao INSERT na outbox para não falhar quando o evento_id já existe
+	ignoraOutboxRepetida string
+	// travaUsuario grava a linha do usuário em travas_usuario, travando-a até o fim da transação
+	travaUsuario string
+}
+
+// querier é o que *sql.DB e *sql.Tx têm em comum, para reaproveitar consultas dentro de transações
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// withTimeout aplica ao contexto recebido o limite por operação configurado
+func (r *sqlRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+// --- Transações ---
+
+// WithTx executa fn com um repositório amarrado a uma sql.Tx: commit se fn retornar nil, rollback caso contrário.
+// Chamadas aninhadas reaproveitam a transação já aberta.
+func (r *sqlRepository) WithTx(ctx context.Context, fn func(repo interfaces.ListaRepository) error) error {
+	if _, emTx := r.q.(*sql.Tx); emTx {
+		return fn(r)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&sqlRepository{db: r.db, q: tx, timeout: r.timeout, dialeto: r.dialeto}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TravaUsuario trava a linha do usuário em travas_usuario, criando-a na primeira vez.
+// Outra transação que peça a mesma trava espera o commit ou o rollback desta.
+func (r *sqlRepository) TravaUsuario(ctx context.Context, userID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.q.ExecContext(ctx, r.dialeto.travaUsuario, userID)
+	return err
+}
+
+// --- Listas ---
+
+func (r *sqlRepository) CountOpenLists(ctx context.Context, userID string) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var count int
+	query := "SELECT COUNT(*) FROM listas WHERE user_id = ? AND status = 'ABERTA' AND deleted_at IS NULL"
+	err := r.q.QueryRowContext(ctx, query, userID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetOpenList retorna a lista ABERTA mais recente criada pelo usuário, com os itens, ou nil se não houver.
+// A busca da lista usa o índice idx_user_status (user_id, status).
+func (r *sqlRepository) GetOpenList(ctx context.Context, userID string) (*listas.Lista, error) {
+	return r.getOpenList(ctx, "listas l", "l.user_id = ?", userID)
+}
+
+func (r *sqlRepository) GetOpenListMembro(ctx context.Context, userID string) (*listas.Lista, error) {
+	from := "listas l JOIN lista_membros m ON m.lista_id = l.id"
+	return r.getOpenList(ctx, from, "m.user_id = ? AND m.aceito_em IS NOT NULL", userID)
+}
+
+// getOpenList busca em from a lista ABERTA mais recente que atende à condição do usuário
+func (r *sqlRepository) getOpenList(ctx context.Context, from, condicao string, userID string) (*listas.Lista, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT l.id, l.user_id, l.nome, l.status, l.total_previsto, l.total_final, l.finalizada_em, l.created_at, l.updated_at
+		FROM ` + from + `
+		WHERE ` + condicao + ` AND l.status = 'ABERTA' AND l.deleted_at IS NULL
+		ORDER BY l.created_at DESC, l.id DESC LIMIT 1
+	`
+
+	lista := &listas.Lista{}
+	err := r.q.QueryRowContext(ctx, query, userID).Scan(
+		&lista.ID, &lista.UserID, &lista.Nome, &lista.Status,
+		&lista.TotalPrevisto, &lista.TotalFinal, &lista.FinalizadaEm, &lista.CreatedAt, &lista.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	itens, err := r.getItemsByListaID(ctx, lista.ID)
+	if err != nil {
+		return nil, err
+	}
+	lista.Itens = itens
+
+	return lista, nil
+}
+
+func (r *sqlRepository) Create(ctx context.Context, lista *listas.Lista) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO listas (user_id, nome, status, total_previsto, total_final) VALUES (?, ?, ?, ?, ?)"
+	res, err := r.q.ExecContext(ctx, query, lista.UserID, lista.Nome, lista.Status, lista.TotalPrevisto, lista.TotalFinal)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// FindByID busca a lista sem filtrar pelo usuário; o acesso é verificado no service pelos membros da lista
+func (r *sqlRepository) FindByID(ctx context.Context, id int64) (*listas.Lista, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT id, user_id, nome, status, total_previsto, total_final, finalizada_em, created_at, updated_at FROM listas WHERE id = ? AND deleted_at IS NULL"
+
+	lista := &listas.Lista{}
+	err := r.q.QueryRowContext(ctx, query, id).Scan(
+		&lista.ID, &lista.UserID, &lista.Nome, &lista.Status,
+		&lista.TotalPrevisto, &lista.TotalFinal, &lista.FinalizadaEm, &lista.CreatedAt, &lista.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	itens, err := r.getItemsByListaID(ctx, lista.ID)
+	if err != nil {
+		return nil, err
+	}
+	lista.Itens = itens
+
+	return lista, nil
+}
+
+// UpdateStatus grava status, totais e data de finalização, desde que a lista ainda esteja no status "de"
+func (r *sqlRepository) UpdateStatus(ctx context.Context, lista *listas.Lista, de listas.StatusLista) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE listas SET status=?, total_previsto=?, total_final=?, finalizada_em=? WHERE id=? AND user_id=? AND status=? AND deleted_at IS NULL"
+	result, err := r.q.ExecContext(ctx, query, lista.Status, lista.TotalPrevisto, lista.TotalFinal, lista.FinalizadaEm, lista.ID, lista.UserID, de)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	// Nenhuma linha alterada: a lista não existe ou já não está mais no status esperado
+	var count int
+	err = r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM listas WHERE id=? AND user_id=? AND deleted_at IS NULL", lista.ID, lista.UserID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return listas.ErrListaNaoEncontrada
+	}
+	return listas.ErrTransicaoInvalida
+}
+
+// GetAll traz as listas em que o usuário é membro ativo (as próprias e as compartilhadas com ele)
+// e calcula a contagem de itens de todas as listas da página numa única consulta agregada.
+// A busca parte de lista_membros pelo índice idx_membro_ativo e chega a cada lista pela chave primária.
+func (r *sqlRepository) GetAll(ctx context.Context, userID string, filtro listas.FiltroListas) (*listas.PaginaListas, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	from := "listas l JOIN lista_membros m ON m.lista_id = l.id AND m.user_id = ? AND m.aceito_em IS NOT NULL"
+	where := "l.deleted_at IS NULL"
+	args := []any{userID}
+	if filtro.Status != nil {
+		where += " AND l.status = ?"
+		args = append(args, *filtro.Status)
+	}
+	if filtro.Nome != "" {
+		// "!" como escape funciona igual no MySQL e no SQLite
+		where += " AND l.nome LIKE ? ESCAPE '!'"
+		args = append(args, "%"+escapeLike(filtro.Nome)+"%")
+	}
+	if filtro.CriadaDe != nil {
+		where += " AND l.created_at >= ?"
+		args = append(args, r.dialeto.data(*filtro.CriadaDe))
+	}
+	if filtro.CriadaAte != nil {
+		where += " AND l.created_at < ?"
+		args = append(args, r.dialeto.data(*filtro.CriadaAte))
+	}
+
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from+" WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// Paginação por cursor: continua logo depois da última lista devolvida (campo de ordenação, ID)
+	coluna := "l." + string(filtro.Ordenacao.Campo)
+	if filtro.Ordenacao.Campo == listas.OrdenaNome {
+		coluna += r.dialeto.colacaoNome
+	}
+	sentido, comparacao := "ASC", ">"
+	if filtro.Ordenacao.Desc {
+		sentido, comparacao = "DESC", "<"
+	}
+	if filtro.Cursor != nil {
+		valor, err := r.valorCursor(filtro.Cursor, filtro.Ordenacao.Campo)
+		if err != nil {
+			return nil, err
+		}
+		where += " AND (" + coluna + " " + comparacao + " ? OR (" + coluna + " = ? AND l.id " + comparacao + " ?))"
+		args = append(args, valor, valor, filtro.Cursor.ID)
+	}
+
+	query := `
+		SELECT l.id, l.user_id, l.nome, l.status, l.total_previsto, l.total_final, l.finalizada_em, l.created_at, l.updated_at, m.papel,
+			COUNT(i.id), COALESCE(SUM(CASE WHEN i.checked THEN 1 ELSE 0 END), 0)
+		FROM ` + from + `
+		LEFT JOIN itens_lista i ON i.lista_id = l.id AND i.deleted_at IS NULL
+		WHERE ` + where + `
+		GROUP BY l.id, m.papel
+		ORDER BY ` + coluna + " " + sentido + ", l.id " + sentido + `
+		LIMIT ?`
+	rows, err := r.q.QueryContext(ctx, query, append(args, filtro.Limite+1)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resumos []*listas.ResumoLista
+	for rows.Next() {
+		l := &listas.ResumoLista{}
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Nome, &l.Status, &l.TotalPrevisto, &l.TotalFinal, &l.FinalizadaEm, &l.CreatedAt, &l.UpdatedAt, &l.Papel, &l.TotalItens, &l.ItensMarcados); err != nil {
+			return nil, err
+		}
+		l.CalculaProgresso()
+		resumos = append(resumos, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return listas.NovaPagina(resumos, total, filtro), nil
+}
+
+// valorCursor converte o valor do cursor no parâmetro comparável com a coluna de ordenação
+func (r *sqlRepository) valorCursor(cursor *listas.Cursor, campo listas.CampoOrdenacao) (any, error) {
+	if campo == listas.OrdenaNome {
+		return cursor.Valor, nil
+	}
+	t, err := cursor.Data()
+	if err != nil {
+		return nil, err
+	}
+	return r.dialeto.data(t), nil
+}
+
+// escapeLike protege os curingas do LIKE, usando "!" como caractere de escape
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+func (r *sqlRepository) Update(ctx context.Context, lista *listas.Lista) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE listas SET nome=?, status=?, total_previsto=?, total_final=? WHERE id=? AND user_id=? AND deleted_at IS NULL"
+	_, err := r.q.ExecContext(ctx, query, lista.Nome, lista.Status, lista.TotalPrevisto, lista.TotalFinal, lista.ID, lista.UserID)
+	return err
+}
+
+// --- Auditoria ---
+
+func (r *sqlRepository) InsertAuditoria(ctx context.Context, auditoria *listas.Auditoria) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO listas_auditoria (lista_id, user_id, acao, status_anterior, status_novo) VALUES (?, ?, ?, ?, ?)"
+	res, err := r.q.ExecContext(ctx, query, auditoria.ListaID, auditoria.UserID, auditoria.Acao, auditoria.StatusAnterior, auditoria.StatusNovo)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	auditoria.ID = id
+	return nil
+}
+
+// --- Itens ---
+
+func (r *sqlRepository) AddItem(ctx context.Context, item *listas.ItemLista) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO itens_lista (lista_id, produto_id, mercado_id, quantidade, preco_unitario, checked, checked_by) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	res, err := r.q.ExecContext(ctx, query, item.ListaID, item.ProdutoID, item.MercadoID, item.Quantidade, item.PrecoUnitario, item.Checked, item.CheckedBy)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	item.ID = id
+	return nil
+}
+
+func (r *sqlRepository) RemoveItem(ctx context.Context, itemID int64) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE itens_lista SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?"
+	_, err := r.q.ExecContext(ctx, query, itemID)
+	return err
+}
+
+func (r *sqlRepository) UpdateItem(ctx context.Context, item *listas.ItemLista) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE itens_lista SET quantidade=?, preco_unitario=?, checked=?, checked_by=?, mercado_id=?, preco_pago=?, mercado_pago_id=? WHERE id=? AND deleted_at IS NULL"
+	_, err := r.q.ExecContext(ctx, query, item.Quantidade, item.PrecoUnitario, item.Checked, item.CheckedBy, item.MercadoID, item.PrecoPago, item.MercadoPagoID, item.ID)
+	return err
+}
+
+func (r *sqlRepository) GetItem(ctx context.Context, itemID int64) (*listas.ItemLista, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT id, lista_id, produto_id, mercado_id, quantidade, preco_unitario, checked, checked_by, preco_pago, mercado_pago_id FROM itens_lista WHERE id = ? AND deleted_at IS NULL"
+	item := &listas.ItemLista{}
+	err := r.q.QueryRowContext(ctx, query, itemID).Scan(&item.ID, &item.ListaID, &item.ProdutoID, &item.MercadoID, &item.Quantidade, &item.PrecoUnitario, &item.Checked, &item.CheckedBy, &item.PrecoPago, &item.MercadoPagoID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Auxiliar privado para buscar itens
+func (r *sqlRepository) getItemsByListaID(ctx context.Context, listaID int64) ([]listas.ItemLista, error) {
+	query := "SELECT id, lista_id, produto_id, mercado_id, quantidade, preco_unitario, checked, checked_by, preco_pago, mercado_pago_id FROM itens_lista WHERE lista_id = ? AND deleted_at IS NULL"
+	rows, err := r.q.QueryContext(ctx, query, listaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var itens []listas.ItemLista
+	for rows.Next() {
+		var i listas.ItemLista
+		if err := rows.Scan(&i.ID, &i.ListaID, &i.ProdutoID, &i.MercadoID, &i.Quantidade, &i.PrecoUnitario, &i.Checked, &i.CheckedBy, &i.PrecoPago, &i.MercadoPagoID); err != nil {
+			return nil, err
+		}
+		itens = append(itens, i)
+	}
+	return itens, nil
+}
+
+// --- Membros ---
+
+func (r *sqlRepository) AddMembro(ctx context.Context, membro *listas.Membro) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO lista_membros (lista_id, user_id, papel, convidado_por, aceito_em) VALUES (?, ?, ?, ?, ?)"
+	_, err := r.q.ExecContext(ctx, query, membro.ListaID, membro.UserID, membro.Papel, membro.ConvidadoPor, membro.AceitoEm)
+	return err
+}
+
+// GetMembro retorna a participação do usuário na lista (ativa ou pendente) ou nil se não houver
+func (r *sqlRepository) GetMembro(ctx context.Context, listaID int64, userID string) (*listas.Membro, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT lista_id, user_id, papel, convidado_por, aceito_em, created_at FROM lista_membros WHERE lista_id = ? AND user_id = ?"
+	m := &listas.Membro{}
+	err := r.q.QueryRowContext(ctx, query, listaID, userID).Scan(&m.ListaID, &m.UserID, &m.Papel, &m.ConvidadoPor, &m.AceitoEm, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (r *sqlRepository) GetMembros(ctx context.Context, listaID int64) ([]listas.Membro, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT lista_id, user_id, papel, convidado_por, aceito_em, created_at FROM lista_membros WHERE lista_id = ? ORDER BY created_at, user_id"
+	return r.queryMembros(ctx, query, listaID)
+}
+
+// GetConvitesPendentes retorna os convites ainda não aceitos pelo usuário, em listas não removidas
+func (r *sqlRepository) GetConvitesPendentes(ctx context.Context, userID string) ([]listas.Membro, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT m.lista_id, m.user_id, m.papel, m.convidado_por, m.aceito_em, m.created_at
+		FROM lista_membros m
+		JOIN listas l ON l.id = m.lista_id AND l.deleted_at IS NULL
+		WHERE m.user_id = ? AND m.aceito_em IS NULL
+		ORDER BY m.created_at DESC, m.lista_id DESC`
+	return r.queryMembros(ctx, query, userID)
+}
+
+func (r *sqlRepository) AceitaConvite(ctx context.Context, listaID int64, userID string, em time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE lista_membros SET aceito_em = ? WHERE lista_id = ? AND user_id = ? AND aceito_em IS NULL"
+	_, err := r.q.ExecContext(ctx, query, em, listaID, userID)
+	return err
+}
+
+func (r *sqlRepository) RemoveMembro(ctx context.Context, listaID int64, userID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM lista_membros WHERE lista_id = ? AND user_id = ?"
+	_, err := r.q.ExecContext(ctx, query, listaID, userID)
+	return err
+}
+
+func (r *sqlRepository) queryMembros(ctx context.Context, query string, args ...any) ([]listas.Membro, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var membros []listas.Membro
+	for rows.Next() {
+		var m listas.Membro
+		if err := rows.Scan(&m.ListaID, &m.UserID, &m.Papel, &m.ConvidadoPor, &m.AceitoEm, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		membros = append(membros, m)
+	}
+	return membros, rows.Err()
+}
+
+// --- Links de compartilhamento ---
+
+func (r *sqlRepository) CreateLink(ctx context.Context, link *listas.LinkCompartilhamento) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	link.CreatedAt = time.Now().Truncate(time.Second)
+	query := "INSERT INTO lista_links (lista_id, criado_por, expira_em, created_at) VALUES (?, ?, ?, ?)"
+	res, err := r.q.ExecContext(ctx, query, link.ListaID, link.CriadoPor, r.dialeto.data(link.ExpiraEm), r.dialeto.data(link.CreatedAt))
+	if err != nil {
+		return err
+	}
+	link.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *sqlRepository) GetLink(ctx context.Context, id int64) (*listas.LinkCompartilhamento, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT id, lista_id, criado_por, expira_em, revogado_em, created_at FROM lista_links WHERE id = ?"
+	l := &listas.LinkCompartilhamento{}
+	err := r.q.QueryRowContext(ctx, query, id).Scan(&l.ID, &l.ListaID, &l.CriadoPor, &l.ExpiraEm, &l.RevogadoEm, &l.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (r *sqlRepository) GetLinksAtivos(ctx context.Context, listaID int64, agora time.Time) ([]listas.LinkCompartilhamento, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT id, lista_id, criado_por, expira_em, revogado_em, created_at FROM lista_links WHERE lista_id = ? AND revogado_em IS NULL AND expira_em > ? ORDER BY id DESC"
+	rows, err := r.q.QueryContext(ctx, query, listaID, r.dialeto.data(agora))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []listas.LinkCompartilhamento
+	for rows.Next() {
+		var l listas.LinkCompartilhamento
+		if err := rows.Scan(&l.ID, &l.ListaID, &l.CriadoPor, &l.ExpiraEm, &l.RevogadoEm, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+func (r *sqlRepository) RevogaLink(ctx context.Context, id int64, em time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE lista_links SET revogado_em = ? WHERE id = ? AND revogado_em IS NULL"
+	_, err := r.q.ExecContext(ctx, query, r.dialeto.data(em), id)
+	return err
+}
+
+// --- Atualização em Massa (RF4) ---
+
+// UpdatePriceInOpenLists atualiza o preço dos itens não comprados em listas ABERTAS e retorna as listas afetadas.
+// Dentro de WithTx as listas ficam travadas até o fim da transação, para o recálculo dos totais.
+func (r *sqlRepository) UpdatePriceInOpenLists(ctx context.Context, produtoID int64, mercadoID int64, novoPreco float64) ([]int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	listaIDs, err := r.openListIDsByItem(ctx, produtoID, mercadoID)
+	if err != nil {
+		return nil, err
+	}
+	if len(listaIDs) == 0 {
+		return nil, nil
+	}
+
+	// Atualiza o preço unitário de itens que estão em listas ABERTAS e correspondem ao produto/mercado.
+	// Subconsulta em vez de UPDATE ... JOIN, que não existe no SQLite.
+	query := `
+		UPDATE itens_lista
+		SET preco_unitario = ?
+		WHERE produto_id = ?
+		  AND mercado_id = ?
+		  AND checked = FALSE -- não mudar preço se já comprou
+		  AND deleted_at IS NULL
+		  AND lista_id IN (SELECT id FROM listas WHERE status = 'ABERTA' AND deleted_at IS NULL)
+	`
+	result, err := r.q.ExecContext(ctx, query, novoPreco, produtoID, mercadoID)
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	log.Printf("Preço atualizado em %d itens de listas abertas.", rowsAffected)
+
+	return listaIDs, nil
+}
+
+// SavePrecoAtual guarda o último preço conhecido do produto no mercado, a menos que o guardado seja mais recente.
+// Um evento sem modified_at (zero) não tem como ser ordenado: é aplicado, e o próximo evento também será.
+func (r *sqlRepository) SavePrecoAtual(ctx context.Context, produtoID int64, mercadoID int64, preco float64, modificadoEm time.Time) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var guardado sql.NullTime
+	query := "SELECT modificado_em FROM precos_mercado WHERE produto_id = ? AND mercado_id = ?" + r.dialeto.lockLinhas
+	err := r.q.QueryRowContext(ctx, query, produtoID, mercadoID).Scan(&guardado)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	if guardado.Valid && !modificadoEm.IsZero() && modificadoEm.Before(guardado.Time) {
+		return false, nil
+	}
+
+	// Comparado em Go, não no SQL: vai como time.Time, sem perder as frações de segundo
+	var modificacao any
+	if !modificadoEm.IsZero() {
+		modificacao = modificadoEm
+	}
+	if _, err := r.q.ExecContext(ctx, r.dialeto.upsertPreco, produtoID, mercadoID, preco, modificacao); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetPrecoAtual retorna o último preço recebido para o produto no mercado, ou nil se ainda não for conhecido
+func (r *sqlRepository) GetPrecoAtual(ctx context.Context, produtoID int64, mercadoID int64) (*float64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var preco float64
+	err := r.q.QueryRowContext(ctx, "SELECT preco_unitario FROM precos_mercado WHERE produto_id = ? AND mercado_id = ?", produtoID, mercadoID).Scan(&preco)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &preco, nil
+}
+
+// Auxiliar privado: listas ABERTAS com itens não comprados do produto/mercado
+func (r *sqlRepository) openListIDsByItem(ctx context.Context, produtoID int64, mercadoID int64) ([]int64, error) {
+	query := `
+		SELECT DISTINCT l.id
+		FROM listas l
+		JOIN itens_lista il ON il.lista_id = l.id
+		WHERE il.produto_id = ?
+		  AND il.mercado_id = ?
+		  AND l.status = 'ABERTA'
+		  AND il.checked = FALSE
+		  AND il.deleted_at IS NULL
+		  AND l.deleted_at IS NULL
+		ORDER BY l.id
+	` + r.dialeto.lockLinhas
+	rows, err := r.q.QueryContext(ctx, query, produtoID, mercadoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// --- Outbox ---
+
+func (r *sqlRepository) InsertOutbox(ctx context.Context, msg *listas.MensagemOutbox) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	msg.CreatedAt = time.Now().Truncate(time.Second)
+	msg.ProximaTentativa = msg.CreatedAt
+	query := "INSERT INTO outbox (evento_id, tipo, payload, proxima_tentativa, created_at) VALUES (?, ?, ?, ?, ?)" + r.dialeto.ignoraOutboxRepetida
+	res, err := r.q.ExecContext(ctx, query, msg.EventoID, msg.Tipo, msg.Payload, r.dialeto.data(msg.ProximaTentativa), r.dialeto.data(msg.CreatedAt))
+	if err != nil {
+		return err
+	}
+	inseridas, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inseridas == 0 {
+		// Evento repetido: fica valendo a mensagem já gravada
+		return r.q.QueryRowContext(ctx, "SELECT id FROM outbox WHERE evento_id = ?", msg.EventoID).Scan(&msg.ID)
+	}
+	msg.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *sqlRepository) GetOutboxPendentes(ctx context.Context, agora time.Time, limite int) ([]listas.MensagemOutbox, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, evento_id, tipo, payload, tentativas, ultimo_erro, proxima_tentativa, enviado_em, created_at
+		FROM outbox
+		WHERE enviado_em IS NULL AND proxima_tentativa <= ?
+		ORDER BY id
+		LIMIT ?
+	` + r.dialeto.lockPulando
+	rows, err := r.q.QueryContext(ctx, query, r.dialeto.data(agora), limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []listas.MensagemOutbox
+	for rows.Next() {
+		var m listas.MensagemOutbox
+		if err := rows.Scan(&m.ID, &m.EventoID, &m.Tipo, &m.Payload, &m.Tentativas, &m.UltimoErro, &m.ProximaTentativa, &m.EnviadoEm, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+func (r *sqlRepository) ReservaOutbox(ctx context.Context, id int64, ate time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.q.ExecContext(ctx, "UPDATE outbox SET proxima_tentativa = ? WHERE id = ?", r.dialeto.data(ate), id)
+	return err
+}
+
+func (r *sqlRepository) MarcaOutboxEnviada(ctx context.Context, id int64, em time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.q.ExecContext(ctx, "UPDATE outbox SET enviado_em = ? WHERE id = ?", r.dialeto.data(em), id)
+	return err
+}
+
+func (r *sqlRepository) MarcaOutboxFalha(ctx context.Context, id int64, erro string, proximaTentativa time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE outbox SET tentativas = tentativas + 1, ultimo_erro = ?, proxima_tentativa = ? WHERE id = ?"
+	_, err := r.q.ExecContext(ctx, query, erro, r.dialeto.data(proximaTentativa), id)
+	return err
+}
+
+func (r *sqlRepository) GetBacklogOutbox(ctx context.Context) (*listas.BacklogOutbox, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	backlog := &listas.BacklogOutbox{}
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM outbox WHERE enviado_em IS NULL").Scan(&backlog.Pendentes); err != nil {
+		return nil, err
+	}
+	if backlog.Pendentes == 0 {
+		return backlog, nil
+	}
+
+	// Pela coluna, e não por MIN(), para o driver do SQLite devolver um time.Time
+	var maisAntiga time.Time
+	err := r.q.QueryRowContext(ctx, "SELECT created_at FROM outbox WHERE enviado_em IS NULL ORDER BY id LIMIT 1").Scan(&maisAntiga)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		backlog.MaisAntiga = &maisAntiga
+	}
+	return backlog, nil
+}
+
+func (r *sqlRepository) RemoveOutboxEnviadas(ctx context.Context, antes time.Time) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.q.ExecContext(ctx, "DELETE FROM outbox WHERE enviado_em IS NOT NULL AND enviado_em < ?", r.dialeto.data(antes))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"comparei-servico-listas/internal/infrastructure/migration"
+	"comparei-servico-listas/migrations"
+	"context"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+type SQLiteRepository struct {
+	*sqlRepository
+}
+
+// NewSQLiteRepository abre (ou cria) o banco no caminho informado e aplica as migrações pendentes.
+// Um banco numa versão mais nova do que a conhecida pelo binário é recusado.
+func NewSQLiteRepository(path string, timeout time.Duration) (*SQLiteRepository, error) {
+	db, err := OpenSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewSQLiteMigrator(db)
+	if err == nil {
+		_, err = migrator.Up(context.Background())
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteRepository{&sqlRepository{
+		db:      db,
+		q:       db,
+		timeout: timeout,
+		dialeto: dialeto{
+			// Transações no SQLite já travam o banco inteiro para escrita
+			lockLinhas:  "",
+			lockPulando: "",
+			// Mesmo formato do CURRENT_TIMESTAMP, para que a comparação entre textos funcione
+			data: func(t time.Time) any { return t.UTC().Format("2006-01-02 15:04:05") },
+			upsertPreco: `
+				INSERT INTO precos_mercado (produto_id, mercado_id, preco_unitario, modificado_em) VALUES (?, ?, ?, ?)
+				ON CONFLICT (produto_id, mercado_id) DO UPDATE SET
+					preco_unitario = excluded.preco_unitario, modificado_em = excluded.modificado_em, updated_at = CURRENT_TIMESTAMP
+			`,
+			// A colação padrão do SQLite (BINARY) colocaria "Zebra" antes de "abacate"
+			colacaoNome:          " COLLATE NOCASE",
+			ignoraOutboxRepetida: " ON CONFLICT (evento_id) DO NOTHING",
+			travaUsuario:         "INSERT INTO travas_usuario (user_id) VALUES (?) ON CONFLICT (user_id) DO NOTHING",
+		},
+	}}, nil
+}
+
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// OpenSQLite abre o banco com as chaves estrangeiras ativas.
+// O SQLite só aceita um escritor por vez, então o pool fica limitado a uma conexão.
+func OpenSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// NewSQLiteMigrator carrega as migrações de migrations/sqlite
+func NewSQLiteMigrator(db *sql.DB) (*migration.Migrator, error) {
+	return migration.New(db, migrations.SQLite, "sqlite", migration.LockSQLite())
+}
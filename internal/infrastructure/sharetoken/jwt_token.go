@@ -0,0 +1,50 @@
+package sharetoken
+
+import (
+	"comparei-servico-listas/internal/domain/listas"
+	"fmt"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Audience dos tokens de compartilhamento: impede que sejam aceitos no lugar de outros JWTs do sistema
+const audiencia = "comparei-listas-compartilhamento"
+
+// JWTToken assina os links com HMAC-SHA256. O segredo deve ser diferente do USER_JWT_SECRET.
+type JWTToken struct {
+	secret []byte
+}
+
+func NewJWTToken(secret string) *JWTToken {
+	return &JWTToken{secret: []byte(secret)}
+}
+
+func (t *JWTToken) Gera(link *listas.LinkCompartilhamento) (string, error) {
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatInt(link.ID, 10),
+		Audience:  jwt.ClaimStrings{audiencia},
+		ExpiresAt: jwt.NewNumericDate(link.ExpiraEm),
+		IssuedAt:  jwt.NewNumericDate(link.CreatedAt),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.secret)
+}
+
+func (t *JWTToken) Valida(token string) (int64, error) {
+	claims := &jwt.RegisteredClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return t.secret, nil
+	})
+	if err != nil || !parsed.Valid || !claims.VerifyAudience(audiencia, true) {
+		return 0, listas.ErrLinkInvalido
+	}
+
+	linkID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return 0, listas.ErrLinkInvalido
+	}
+	return linkID, nil
+}
@@ -2,15 +2,27 @@ package main
 
 import (
 	"comparei-servico-listas/internal/app"
+	interfaces "comparei-servico-listas/internal/domain/interface"
+	"comparei-servico-listas/internal/domain/listas"
+	"comparei-servico-listas/internal/infrastructure/eventbus"
 	"comparei-servico-listas/internal/infrastructure/http"
+	"comparei-servico-listas/internal/infrastructure/http/middleware"
+	"comparei-servico-listas/internal/infrastructure/messaging/outbox"
+	"comparei-servico-listas/internal/infrastructure/messaging/publisher"
 	"comparei-servico-listas/internal/infrastructure/messaging/subscriber"
 	"comparei-servico-listas/internal/infrastructure/repository"
+	"comparei-servico-listas/internal/infrastructure/sharetoken"
 	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	httpNet "net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -25,18 +37,40 @@ func main() {
 		log.Println("Aviso: arquivo .env não encontrado, usando variáveis de ambiente do sistema.")
 	}
 
-	// 2. Conexão com MySQL
-	dsn := os.Getenv("MYSQL_USER") + ":" + os.Getenv("MYSQL_PASSWORD") + "@tcp(" + os.Getenv("MYSQL_HOST") + ")/" + os.Getenv("MYSQL_DB") + "?parseTime=true"
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		log.Fatal("Erro ao abrir conexão MySQL:", err)
+	// Subcomando de migrações: ./main migrate up|down|status|force
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
 	}
-	defer db.Close()
 
-	if err := db.Ping(); err != nil {
-		log.Fatal("Erro ao conectar no MySQL:", err)
+	// 2. Repositório: MySQL (padrão), SQLite (REPOSITORY_BACKEND=sqlite) ou memória (REPOSITORY_BACKEND=memory)
+	var listaRepo interfaces.ListaRepository
+	switch backend := os.Getenv("REPOSITORY_BACKEND"); backend {
+	case "", "mysql":
+		db := openMySQL()
+		defer db.Close()
+
+		// Não sobe com um schema diferente do que este binário conhece
+		if err := newMigrator(db).Check(context.Background()); err != nil {
+			log.Fatal("Schema do MySQL incompatível: ", err)
+		}
+		listaRepo = repository.NewMySQLRepository(db, queryTimeoutFromEnv())
+	case "sqlite":
+		// As migrações de migrations/sqlite são aplicadas ao abrir o banco
+		path := sqlitePathFromEnv()
+		sqliteRepo, err := repository.NewSQLiteRepository(path, queryTimeoutFromEnv())
+		if err != nil {
+			log.Fatal("Erro ao abrir banco SQLite:", err)
+		}
+		defer sqliteRepo.Close()
+		log.Println("✅ Banco SQLite aberto em " + path)
+		listaRepo = sqliteRepo
+	case "memory":
+		log.Println("⚠️ Usando repositório em memória.")
+		listaRepo = repository.NewMemoryRepository()
+	default:
+		log.Fatal("REPOSITORY_BACKEND desconhecido: ", backend)
 	}
-	log.Println("✅ Conexão com MySQL estabelecida com sucesso!")
 
 	// 3. Conexão com Redis (Mensageria)
 	redisHost := os.Getenv("REDIS_MESSAGING_HOST")
@@ -56,27 +90,42 @@ func main() {
 
 	// 4. Inicialização de Dependências (Injeção de Dependência)
 
-	// Repository
-	listaRepo := repository.NewMySQLRepository(db)
+	// Eventos das listas em tempo real, repassados entre as réplicas pelo Redis
+	canalEventos := os.Getenv("LISTAS_EVENTOS_CHANNEL")
+	if canalEventos == "" {
+		canalEventos = "listas_eventos"
+	}
+	eventos := eventbus.NewRedis(rdb, canalEventos)
 
 	// Service
-	listaService := app.NewListaService(listaRepo)
+	listaService := app.NewListaService(listaRepo, politicaFromEnv(), tokensFromEnv(), eventos)
 
 	// Handler
 	listaHandler := http.NewListaHandler(listaService)
 
+	// Contexto cancelado ao receber SIGINT/SIGTERM: encerra o subscriber e as queries em andamento
+	appCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// 5. Configurar Subscriber (Mensageria)
 	// Injeta o service no subscriber para que ele possa chamar a lógica de negócio
 	subscriber.SetListaService(listaService)
 
 	// Inicia o subscriber em uma Goroutine (background) para não bloquear o servidor HTTP
+	precos := precosFromEnv()
 	go func() {
 		log.Println("📡 Iniciando Subscriber...")
-		subscriber.SubPriceUpdates()
+		subscriber.SubPriceUpdates(appCtx, precos)
 	}()
+	go eventos.Escuta(appCtx)
+
+	// Relay da outbox: publica no Redis os eventos gravados junto com as alterações das listas
+	relay := outbox.NewRelay(listaRepo, publisher.NewRedis(rdb), outboxIntervaloFromEnv())
+	go relay.Run(appCtx)
 
 	// 6. Configurar Roteamento e Servidor HTTP
-	router := http.NewRouter(listaHandler)
+	adminHandler := http.NewAdminHandler(subscriber.NewDeadLetters(rdb, precos))
+	router := http.NewRouter(listaHandler, adminHandler, middleware.NewRateLimiter(publicRateLimitFromEnv(), time.Minute, trustedProxiesFromEnv()))
 
 	// Middleware de Autenticação (Sugestão Simplificada)
 	// Aqui você deve garantir que o handler consiga extrair o ID do usuário.
@@ -87,8 +136,204 @@ func main() {
 		serverPort = "8083" // Porta padrão sugerida para o serviço de listas
 	}
 
+	server := &httpNet.Server{
+		Addr:    ":" + serverPort,
+		Handler: router,
+	}
+	// Shutdown não espera conexões de streaming terminarem sozinhas: encerra as assinaturas
+	server.RegisterOnShutdown(eventos.Encerra)
+
+	go func() {
+		<-appCtx.Done()
+		log.Println("Encerrando servidor HTTP...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
 	log.Println("🚀 Servidor rodando na porta " + serverPort)
-	if err := httpNet.ListenAndServe(":"+serverPort, router); err != nil {
+	if err := server.ListenAndServe(); err != nil && err != httpNet.ErrServerClosed {
 		log.Fatal("Erro fatal no servidor HTTP:", err)
 	}
 }
+
+// openMySQL abre e testa a conexão com o MySQL
+func openMySQL() *sql.DB {
+	dsn := os.Getenv("MYSQL_USER") + ":" + os.Getenv("MYSQL_PASSWORD") + "@tcp(" + os.Getenv("MYSQL_HOST") + ")/" + os.Getenv("MYSQL_DB") + "?parseTime=true"
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		log.Fatal("Erro ao abrir conexão MySQL:", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		log.Fatal("Erro ao conectar no MySQL:", err)
+	}
+	log.Println("✅ Conexão com MySQL estabelecida com sucesso!")
+	return db
+}
+
+// sqlitePathFromEnv lê o arquivo do banco quando REPOSITORY_BACKEND=sqlite (SQLITE_PATH, padrão listas.db)
+func sqlitePathFromEnv() string {
+	if path := os.Getenv("SQLITE_PATH"); path != "" {
+		return path
+	}
+	return "listas.db"
+}
+
+// queryTimeoutFromEnv lê o limite de cada operação no banco, MySQL ou SQLite (ex.: "5s", "500ms"); "0" desativa
+func queryTimeoutFromEnv() time.Duration {
+	v := os.Getenv("DB_QUERY_TIMEOUT")
+	if v == "" {
+		return 5 * time.Second
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatal("DB_QUERY_TIMEOUT inválido:", err)
+	}
+	return timeout
+}
+
+// politicaFromEnv monta o limite de listas abertas: MAX_LISTAS_ABERTAS (padrão 1, "0" = sem limite)
+// e MAX_LISTAS_ABERTAS_POR_PLANO no formato "free=1,premium=5"
+func politicaFromEnv() listas.PoliticaListasAbertas {
+	politica := listas.PoliticaUmaListaAberta
+
+	if v := os.Getenv("MAX_LISTAS_ABERTAS"); v != "" {
+		max, err := strconv.Atoi(v)
+		if err != nil || max < 0 {
+			log.Fatal("MAX_LISTAS_ABERTAS inválido: ", v)
+		}
+		politica.MaxPadrao = max
+	}
+
+	if v := os.Getenv("MAX_LISTAS_ABERTAS_POR_PLANO"); v != "" {
+		politica.PorPlano = map[string]int{}
+		for _, par := range strings.Split(v, ",") {
+			plano, limite, ok := strings.Cut(strings.TrimSpace(par), "=")
+			max, err := strconv.Atoi(limite)
+			if !ok || err != nil || max < 0 {
+				log.Fatal("MAX_LISTAS_ABERTAS_POR_PLANO inválido: ", par)
+			}
+			politica.PorPlano[plano] = max
+		}
+	}
+	return politica
+}
+
+// tokensFromEnv assina os links públicos com SHARE_TOKEN_SECRET; sem ele, o compartilhamento por link fica desativado
+func tokensFromEnv() interfaces.TokenCompartilhamento {
+	secret := os.Getenv("SHARE_TOKEN_SECRET")
+	if secret == "" {
+		log.Println("Aviso: SHARE_TOKEN_SECRET não configurado, links públicos de listas desativados.")
+		return nil
+	}
+	return sharetoken.NewJWTToken(secret)
+}
+
+// outboxIntervaloFromEnv lê de quanto em quanto tempo o relay procura eventos pendentes na outbox (padrão 1s)
+func outboxIntervaloFromEnv() time.Duration {
+	v := os.Getenv("OUTBOX_INTERVALO")
+	if v == "" {
+		return time.Second
+	}
+	intervalo, err := time.ParseDuration(v)
+	if err != nil || intervalo <= 0 {
+		log.Fatal("OUTBOX_INTERVALO inválido: ", v)
+	}
+	return intervalo
+}
+
+// precosFromEnv lê o stream de eventos de preço (PRECOS_STREAM, padrão update_product), o grupo de consumidores
+// (PRECOS_GRUPO, padrão servico-listas), o nome desta réplica no grupo (PRECOS_CONSUMIDOR, padrão o hostname),
+// o stream de dead-letter (PRECOS_DEAD_LETTER_STREAM, padrão o stream de preços + ".dlq") e o canal de Pub/Sub
+// repassado ao stream durante a migração do Promer (PRECOS_CANAL_LEGADO, padrão update_product; vazio desliga a ponte).
+// Um evento que falhou é entregue de novo depois de PRECOS_ESPERA_REENTREGA (padrão 1m), espera que dobra a cada entrega,
+// até PRECOS_TENTATIVAS vezes (padrão 8, cerca de duas horas)
+func precosFromEnv() subscriber.ConfigPrecos {
+	cfg := subscriber.ConfigPrecos{
+		Stream:           os.Getenv("PRECOS_STREAM"),
+		Grupo:            os.Getenv("PRECOS_GRUPO"),
+		Consumidor:       os.Getenv("PRECOS_CONSUMIDOR"),
+		StreamDeadLetter: os.Getenv("PRECOS_DEAD_LETTER_STREAM"),
+	}
+	if cfg.Stream == "" {
+		cfg.Stream = "update_product"
+	}
+	if cfg.Grupo == "" {
+		cfg.Grupo = "servico-listas"
+	}
+	if cfg.Consumidor == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatal("Erro ao obter o hostname para PRECOS_CONSUMIDOR:", err)
+		}
+		cfg.Consumidor = hostname
+	}
+	if cfg.StreamDeadLetter == "" {
+		cfg.StreamDeadLetter = cfg.Stream + ".dlq"
+	}
+	cfg.TentativasMaximas = 8
+	if v := os.Getenv("PRECOS_TENTATIVAS"); v != "" {
+		tentativas, err := strconv.Atoi(v)
+		if err != nil || tentativas <= 0 {
+			log.Fatal("PRECOS_TENTATIVAS inválido: ", v)
+		}
+		cfg.TentativasMaximas = tentativas
+	}
+	cfg.EsperaReentrega = time.Minute
+	if v := os.Getenv("PRECOS_ESPERA_REENTREGA"); v != "" {
+		espera, err := time.ParseDuration(v)
+		if err != nil || espera < time.Millisecond {
+			log.Fatal("PRECOS_ESPERA_REENTREGA inválido: ", v)
+		}
+		cfg.EsperaReentrega = espera
+	}
+	// Aqui vazio é diferente de não definido: desliga a ponte
+	canal, definido := os.LookupEnv("PRECOS_CANAL_LEGADO")
+	if !definido {
+		canal = "update_product" // canal em que o Promer publicava antes do stream
+	}
+	cfg.CanalLegado = canal
+	return cfg
+}
+
+// publicRateLimitFromEnv lê o limite de requisições por minuto, por IP, das rotas públicas (padrão 30)
+func publicRateLimitFromEnv() int {
+	v := os.Getenv("PUBLIC_RATE_LIMIT")
+	if v == "" {
+		return 30
+	}
+	limite, err := strconv.Atoi(v)
+	if err != nil || limite <= 0 {
+		log.Fatal("PUBLIC_RATE_LIMIT inválido: ", v)
+	}
+	return limite
+}
+
+// trustedProxiesFromEnv lê as redes dos proxies reversos na frente do serviço, no formato "10.0.0.0/8,192.168.1.10".
+// Só as conexões vindas delas têm o X-Forwarded-For/X-Real-IP usado como IP do cliente no limite das rotas públicas
+func trustedProxiesFromEnv() []*net.IPNet {
+	v := os.Getenv("TRUSTED_PROXIES")
+	if v == "" {
+		return nil
+	}
+
+	var redes []*net.IPNet
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if net.ParseIP(item) != nil {
+			// IP sozinho: uma rede só com ele
+			if strings.Contains(item, ":") {
+				item += "/128"
+			} else {
+				item += "/32"
+			}
+		}
+		_, rede, err := net.ParseCIDR(item)
+		if err != nil {
+			log.Fatal("TRUSTED_PROXIES inválido: ", item)
+		}
+		redes = append(redes, rede)
+	}
+	return redes
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"comparei-servico-listas/internal/infrastructure/migration"
+	"comparei-servico-listas/internal/infrastructure/repository"
+	"comparei-servico-listas/migrations"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const migrateUsage = `uso: main migrate <comando>
+
+comandos:
+  up            aplica todas as migrações pendentes
+  down [n]      desfaz as últimas n migrações (padrão 1)
+  status        mostra a versão do banco e a mais nova conhecida
+  force <v>     marca o banco na versão v sem executar scripts`
+
+func newMigrator(db *sql.DB) *migration.Migrator {
+	migrator, err := migration.New(db, migrations.MySQL, "mysql", migration.LockMySQL(30*time.Second))
+	if err != nil {
+		log.Fatal("Erro ao carregar migrações:", err)
+	}
+	return migrator
+}
+
+// runMigrate executa o subcomando "migrate" no banco de REPOSITORY_BACKEND e encerra o processo
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	var db *sql.DB
+	var migrator *migration.Migrator
+	switch backend := os.Getenv("REPOSITORY_BACKEND"); backend {
+	case "", "mysql":
+		db = openMySQL()
+		migrator = newMigrator(db)
+	case "sqlite":
+		var err error
+		if db, err = repository.OpenSQLite(sqlitePathFromEnv()); err != nil {
+			log.Fatal("Erro ao abrir banco SQLite:", err)
+		}
+		if migrator, err = repository.NewSQLiteMigrator(db); err != nil {
+			log.Fatal("Erro ao carregar migrações:", err)
+		}
+	default:
+		log.Fatal("REPOSITORY_BACKEND sem migrações: ", backend)
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatal("Erro ao migrar:", err)
+		}
+		log.Printf("✅ %d migrações aplicadas. Versão atual: %d", n, migrator.Latest())
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				log.Fatal("Quantidade inválida: ", args[1])
+			}
+		}
+		desfeitas, err := migrator.Down(ctx, n)
+		if err != nil {
+			log.Fatal("Erro ao desfazer migrações:", err)
+		}
+		log.Printf("✅ %d migrações desfeitas.", desfeitas)
+	case "status":
+		versao, err := migrator.Version(ctx)
+		if err != nil {
+			log.Fatal("Erro ao consultar versão:", err)
+		}
+		fmt.Printf("versão do banco: %d\nversão mais nova: %d\n", versao, migrator.Latest())
+	case "force":
+		if len(args) < 2 {
+			log.Fatal("Informe a versão: migrate force <v>")
+		}
+		versao, err := strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatal("Versão inválida: ", args[1])
+		}
+		if err := migrator.Force(ctx, versao); err != nil {
+			log.Fatal("Erro ao forçar versão:", err)
+		}
+		log.Printf("✅ Banco marcado na versão %d.", versao)
+	default:
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+}
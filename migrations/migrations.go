@@ -0,0 +1,13 @@
+// Package migrations embute no binário os scripts versionados do schema.
+// Cada versão tem um par NNNN_descricao.up.sql / NNNN_descricao.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed mysql/*.sql
+var MySQL embed.FS
+
+// SQLite tem as mesmas versões do MySQL, na sintaxe do SQLite
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
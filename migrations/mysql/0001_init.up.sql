@@ -1,6 +1,3 @@
-CREATE DATABASE IF NOT EXISTS listasdb;
-USE listasdb;
-
 CREATE TABLE IF NOT EXISTS listas (
     id INT AUTO_INCREMENT PRIMARY KEY,
     user_id VARCHAR(36) NOT NULL,
@@ -11,7 +8,7 @@ CREATE TABLE IF NOT EXISTS listas (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     deleted_at TIMESTAMP DEFAULT NULL,
-    INDEX idx_user_status (user_id, status) 
+    INDEX idx_user_status (user_id, status)
 );
 
 CREATE TABLE IF NOT EXISTS itens_lista (
@@ -25,4 +22,4 @@ CREATE TABLE IF NOT EXISTS itens_lista (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     deleted_at TIMESTAMP DEFAULT NULL,
     FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
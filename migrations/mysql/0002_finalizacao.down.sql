@@ -0,0 +1,3 @@
+ALTER TABLE itens_lista DROP COLUMN mercado_pago_id, DROP COLUMN preco_pago;
+
+ALTER TABLE listas DROP COLUMN finalizada_em;
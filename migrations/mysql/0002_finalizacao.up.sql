@@ -0,0 +1,5 @@
+ALTER TABLE listas ADD COLUMN finalizada_em TIMESTAMP NULL DEFAULT NULL AFTER total_final;
+
+ALTER TABLE itens_lista
+    ADD COLUMN preco_pago DECIMAL(10, 2) NULL AFTER checked,
+    ADD COLUMN mercado_pago_id INT NULL AFTER preco_pago;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS listas_auditoria;
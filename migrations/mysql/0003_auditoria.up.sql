@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS listas_auditoria (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    lista_id INT NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    acao VARCHAR(50) NOT NULL,
+    status_anterior ENUM('ABERTA', 'FECHADA', 'CANCELADA') NOT NULL,
+    status_novo ENUM('ABERTA', 'FECHADA', 'CANCELADA') NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_lista (lista_id),
+    FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE
+);
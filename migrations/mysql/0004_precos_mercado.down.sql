@@ -0,0 +1 @@
+DROP TABLE IF EXISTS precos_mercado;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS precos_mercado (
+    produto_id INT NOT NULL,
+    mercado_id INT NOT NULL,
+    preco_unitario DECIMAL(10, 2) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (produto_id, mercado_id)
+);
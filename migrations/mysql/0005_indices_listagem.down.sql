@@ -0,0 +1,3 @@
+DROP INDEX idx_user_created ON listas;
+DROP INDEX idx_user_updated ON listas;
+DROP INDEX idx_user_nome ON listas;
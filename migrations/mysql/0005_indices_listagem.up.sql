@@ -0,0 +1,4 @@
+-- Índices da listagem paginada (GET /listas): um por campo de ordenação, com o ID para desempate do cursor
+CREATE INDEX idx_user_created ON listas (user_id, created_at, id);
+CREATE INDEX idx_user_updated ON listas (user_id, updated_at, id);
+CREATE INDEX idx_user_nome ON listas (user_id, nome, id);
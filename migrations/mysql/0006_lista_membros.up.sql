@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS lista_membros (
+    lista_id INT NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    papel ENUM('owner', 'editor', 'viewer') NOT NULL,
+    convidado_por VARCHAR(36) NOT NULL,
+    aceito_em TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (lista_id, user_id),
+    INDEX idx_membro_user (user_id),
+    FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE
+);
+
+-- Toda lista existente passa a ter o criador como dono
+INSERT INTO lista_membros (lista_id, user_id, papel, convidado_por, aceito_em, created_at)
+SELECT id, user_id, 'owner', user_id, created_at, created_at FROM listas;
+
+ALTER TABLE itens_lista ADD COLUMN checked_by VARCHAR(36) NULL AFTER checked;
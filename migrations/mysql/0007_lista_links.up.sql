@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS lista_links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    lista_id INT NOT NULL,
+    criado_por VARCHAR(36) NOT NULL,
+    expira_em TIMESTAMP NOT NULL,
+    revogado_em TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_link_lista (lista_id),
+    FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    evento_id VARCHAR(32) NOT NULL,
+    tipo VARCHAR(100) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    tentativas INT NOT NULL DEFAULT 0,
+    ultimo_erro TEXT NULL,
+    proxima_tentativa TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    enviado_em TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_outbox_evento (evento_id),
+    INDEX idx_outbox_pendentes (enviado_em, proxima_tentativa, id)
+);
@@ -0,0 +1,6 @@
+CREATE INDEX idx_user_created ON listas (user_id, created_at, id);
+CREATE INDEX idx_user_updated ON listas (user_id, updated_at, id);
+CREATE INDEX idx_user_nome ON listas (user_id, nome, id);
+
+CREATE INDEX idx_membro_user ON lista_membros (user_id);
+DROP INDEX idx_membro_ativo ON lista_membros;
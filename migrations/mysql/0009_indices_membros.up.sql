@@ -0,0 +1,9 @@
+-- A listagem (GET /listas) parte das participações ativas do usuário: o índice atende ao filtro do JOIN
+-- (user_id, aceito_em IS NOT NULL) e já traz o lista_id. Ele torna idx_membro_user redundante.
+CREATE INDEX idx_membro_ativo ON lista_membros (user_id, aceito_em, lista_id);
+DROP INDEX idx_membro_user ON lista_membros;
+
+-- Os índices de listas por user_id da 0005 deixaram de servir à listagem, que agora começa pelos membros
+DROP INDEX idx_user_created ON listas;
+DROP INDEX idx_user_updated ON listas;
+DROP INDEX idx_user_nome ON listas;
@@ -0,0 +1,4 @@
+-- Uma linha por usuário, travada para serializar a conferência do limite de listas abertas
+CREATE TABLE IF NOT EXISTS travas_usuario (
+    user_id VARCHAR(36) PRIMARY KEY
+);
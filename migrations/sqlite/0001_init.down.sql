@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS itens_lista;
+
+DROP TABLE IF EXISTS listas;
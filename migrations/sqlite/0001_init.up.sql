@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS listas (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(36) NOT NULL,
+    nome VARCHAR(255) NOT NULL,
+    status TEXT DEFAULT 'ABERTA' CHECK (status IN ('ABERTA', 'FECHADA', 'CANCELADA')),
+    total_previsto DECIMAL(10, 2) DEFAULT 0.00,
+    total_final DECIMAL(10, 2) DEFAULT 0.00,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_status ON listas (user_id, status);
+
+-- Substitui o ON UPDATE CURRENT_TIMESTAMP do MySQL. O corpo fica numa linha só porque o migrador
+-- separa os comandos pelo ";" no fim da linha.
+CREATE TRIGGER IF NOT EXISTS listas_updated_at AFTER UPDATE ON listas
+FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN UPDATE listas SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id; END;
+
+CREATE TABLE IF NOT EXISTS itens_lista (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    lista_id INTEGER NOT NULL,
+    produto_id INTEGER NOT NULL,
+    mercado_id INTEGER NULL,
+    quantidade DECIMAL(10, 3) NOT NULL DEFAULT 1.000,
+    preco_unitario DECIMAL(10, 2) DEFAULT 0.00,
+    checked BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE
+);
+-- O MySQL cria este índice junto com a FOREIGN KEY; o SQLite não
+CREATE INDEX IF NOT EXISTS idx_itens_lista ON itens_lista (lista_id);
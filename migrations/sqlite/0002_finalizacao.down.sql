@@ -0,0 +1,5 @@
+ALTER TABLE itens_lista DROP COLUMN mercado_pago_id;
+
+ALTER TABLE itens_lista DROP COLUMN preco_pago;
+
+ALTER TABLE listas DROP COLUMN finalizada_em;
@@ -0,0 +1,5 @@
+ALTER TABLE listas ADD COLUMN finalizada_em TIMESTAMP NULL DEFAULT NULL;
+
+ALTER TABLE itens_lista ADD COLUMN preco_pago DECIMAL(10, 2) NULL;
+
+ALTER TABLE itens_lista ADD COLUMN mercado_pago_id INTEGER NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS listas_auditoria (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    lista_id INTEGER NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    acao VARCHAR(50) NOT NULL,
+    status_anterior TEXT NOT NULL CHECK (status_anterior IN ('ABERTA', 'FECHADA', 'CANCELADA')),
+    status_novo TEXT NOT NULL CHECK (status_novo IN ('ABERTA', 'FECHADA', 'CANCELADA')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_lista ON listas_auditoria (lista_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_created;
+DROP INDEX IF EXISTS idx_user_updated;
+DROP INDEX IF EXISTS idx_user_nome;
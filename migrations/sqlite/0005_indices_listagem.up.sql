@@ -0,0 +1,5 @@
+-- Índices da listagem paginada (GET /listas): um por campo de ordenação, com o ID para desempate do cursor.
+-- O nome usa NOCASE, a mesma colação da ordenação (dialeto.colacaoNome).
+CREATE INDEX IF NOT EXISTS idx_user_created ON listas (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_user_updated ON listas (user_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_user_nome ON listas (user_id, nome COLLATE NOCASE, id);
@@ -0,0 +1,3 @@
+ALTER TABLE itens_lista DROP COLUMN checked_by;
+
+DROP TABLE IF EXISTS lista_membros;
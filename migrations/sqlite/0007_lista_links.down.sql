@@ -0,0 +1 @@
+DROP TABLE IF EXISTS lista_links;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS lista_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    lista_id INTEGER NOT NULL,
+    criado_por VARCHAR(36) NOT NULL,
+    expira_em TIMESTAMP NOT NULL,
+    revogado_em TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_link_lista ON lista_links (lista_id);
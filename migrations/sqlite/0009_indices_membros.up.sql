@@ -0,0 +1,9 @@
+-- A listagem (GET /listas) parte das participações ativas do usuário: o índice parcial atende ao filtro do JOIN
+-- e já traz o lista_id. Ele torna idx_membro_user redundante.
+CREATE INDEX idx_membro_ativo ON lista_membros (user_id, lista_id) WHERE aceito_em IS NOT NULL;
+DROP INDEX IF EXISTS idx_membro_user;
+
+-- Os índices de listas por user_id da 0005 deixaram de servir à listagem, que agora começa pelos membros
+DROP INDEX IF EXISTS idx_user_created;
+DROP INDEX IF EXISTS idx_user_updated;
+DROP INDEX IF EXISTS idx_user_nome;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS travas_usuario;
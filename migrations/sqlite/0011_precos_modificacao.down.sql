@@ -0,0 +1 @@
+ALTER TABLE precos_mercado DROP COLUMN modificado_em;
@@ -0,0 +1,2 @@
+-- modified_at do Promer: um evento de preço mais antigo que o guardado é ignorado
+ALTER TABLE precos_mercado ADD COLUMN modificado_em TIMESTAMP NULL;